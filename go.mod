@@ -8,6 +8,7 @@ require (
 	github.com/go-chi/cors v1.2.1
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.1
+	golang.org/x/text v0.14.0
 )
 
 require golang.org/x/net v0.17.0 // indirect
@@ -0,0 +1,122 @@
+package oshirigame
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Letter sources a room can draw its atama and oshiri from.
+const (
+	// LetterSourcePool draws from the room's letter pools with replacement.
+	LetterSourcePool = "pool"
+	// LetterSourceBag draws from a finite bag of tiles that empties over the
+	// game.
+	LetterSourceBag = "bag"
+)
+
+func ValidLetterSource(source string) bool {
+	return source == LetterSourcePool || source == LetterSourceBag
+}
+
+// bagSize is roughly how many tiles a bag holds for languages without a
+// Scrabble set.
+const bagSize = 100
+
+// scrabbleTiles is the English Scrabble set without the blanks.
+var scrabbleTiles = map[string]int{
+	"a": 9, "b": 2, "c": 2, "d": 4, "e": 12, "f": 2, "g": 3, "h": 2, "i": 9,
+	"j": 1, "k": 1, "l": 4, "m": 2, "n": 6, "o": 8, "p": 2, "q": 1, "r": 6,
+	"s": 4, "t": 6, "u": 4, "v": 2, "w": 2, "x": 1, "y": 2, "z": 1,
+}
+
+// LetterBag counts the tiles left for each letter.
+type LetterBag map[string]int
+
+// NewLetterBag fills a bag for the dictionary's language, leaving out the
+// excluded letters. English gets the Scrabble set; other languages get every
+// letter of their alphabet in proportion to how often their words use it.
+func NewLetterBag(dictionary Dictionary, excluded []string) LetterBag {
+	language := dictionary.Language()
+	bag := make(LetterBag)
+	for _, letter := range language.Alphabet {
+		if usesLetter(language, letter, excluded) {
+			continue
+		}
+		if language.Code == English {
+			bag[letter] = scrabbleTiles[letter]
+			continue
+		}
+		bag[letter] = max(1, int(math.Round(dictionary.LetterFrequency(letter)*bagSize)))
+	}
+	return bag
+}
+
+func (b LetterBag) Copy() LetterBag {
+	if b == nil {
+		return nil
+	}
+	copied := make(LetterBag, len(b))
+	for letter, count := range b {
+		copied[letter] = count
+	}
+	return copied
+}
+
+func (b LetterBag) Total() int {
+	total := 0
+	for _, count := range b {
+		total += count
+	}
+	return total
+}
+
+// Draw takes a random tile out of the bag, or returns "" when it is empty.
+func (b LetterBag) Draw(rng *rand.Rand) string {
+	total := b.Total()
+	if total == 0 {
+		return ""
+	}
+
+	// Sorted so that a seeded generator always draws the same tiles.
+	letters := make([]string, 0, len(b))
+	for letter := range b {
+		letters = append(letters, letter)
+	}
+	sort.Strings(letters)
+
+	n := rng.Intn(total)
+	for _, letter := range letters {
+		if n < b[letter] {
+			b[letter]--
+			return letter
+		}
+		n -= b[letter]
+	}
+	return ""
+}
+
+// CanSpell reports whether the bag holds the tiles for every fragment at once.
+func (b LetterBag) CanSpell(language Language, fragments ...string) bool {
+	needed := make(map[string]int)
+	for _, fragment := range fragments {
+		for _, unit := range language.Split(fragment) {
+			needed[unit]++
+		}
+	}
+	for unit, count := range needed {
+		if b[unit] < count {
+			return false
+		}
+	}
+	return true
+}
+
+// Take removes the tiles for the fragments. They must be in the bag.
+func (b LetterBag) Take(language Language, fragments ...string) {
+	for _, fragment := range fragments {
+		for _, unit := range language.Split(fragment) {
+			b[unit]--
+		}
+	}
+}
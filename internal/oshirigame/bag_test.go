@@ -0,0 +1,86 @@
+package oshirigame
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestLetterBagDraw(t *testing.T) {
+	draw := func(seed int64) []string {
+		bag := LetterBag{"a": 3, "b": 2, "c": 1}
+		rng := rand.New(rand.NewSource(seed))
+		var tiles []string
+		for i := 0; i < 6; i++ {
+			tiles = append(tiles, bag.Draw(rng))
+		}
+		if bag.Total() != 0 {
+			t.Errorf("bag has %d tiles left after drawing them all", bag.Total())
+		}
+		if tile := bag.Draw(rng); tile != "" {
+			t.Errorf("empty bag drew %q", tile)
+		}
+		return tiles
+	}
+
+	first, second := draw(42), draw(42)
+	counts := make(map[string]int)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("same seed drew %v and %v", first, second)
+		}
+		counts[first[i]]++
+	}
+	if counts["a"] != 3 || counts["b"] != 2 || counts["c"] != 1 {
+		t.Errorf("drew %v, want every tile once", first)
+	}
+
+	if tile := (LetterBag{}).Draw(rand.New(rand.NewSource(1))); tile != "" {
+		t.Errorf("empty bag drew %q", tile)
+	}
+}
+
+func TestLetterBagCanSpell(t *testing.T) {
+	bag := LetterBag{"a": 2, "b": 1}
+	tests := []struct {
+		fragments []string
+		want      bool
+	}{
+		{[]string{"a"}, true},
+		{[]string{"aa"}, true},
+		{[]string{"aaa"}, false},
+		{[]string{"a", "a"}, true},
+		{[]string{"a", "aa"}, false},
+		{[]string{"ab", "a"}, true},
+		{[]string{"bb"}, false},
+		{[]string{"c"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := bag.CanSpell(LanguageEnglish, tt.fragments...); got != tt.want {
+			t.Errorf("CanSpell(%q) = %v, want %v", tt.fragments, got, tt.want)
+		}
+	}
+}
+
+func TestLetterBagTake(t *testing.T) {
+	bag := LetterBag{"a": 3, "b": 1}
+	bag.Take(LanguageEnglish, "aa", "ab")
+	if bag["a"] != 0 || bag["b"] != 0 {
+		t.Errorf("bag left %v after taking every tile", bag)
+	}
+	if bag.CanSpell(LanguageEnglish, "a") {
+		t.Error("emptied bag can still spell a")
+	}
+}
+
+func TestLetterBagCopy(t *testing.T) {
+	bag := LetterBag{"a": 1}
+	copied := bag.Copy()
+	copied.Take(LanguageEnglish, "a")
+	if bag["a"] != 1 {
+		t.Error("taking from a copy changed the original bag")
+	}
+	if LetterBag(nil).Copy() != nil {
+		t.Error("copy of a nil bag is not nil")
+	}
+}
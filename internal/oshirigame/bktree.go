@@ -0,0 +1,83 @@
+package oshirigame
+
+// bkTree is a Burkhard-Keller tree over edit distance. Searching it for words
+// near a misspelling only visits the subtrees the triangle inequality allows.
+type bkTree struct {
+	root *bkNode
+}
+
+type bkNode struct {
+	word     string
+	children map[int]*bkNode
+}
+
+func (t *bkTree) add(word string) {
+	if t.root == nil {
+		t.root = &bkNode{word: word}
+		return
+	}
+
+	node := t.root
+	for {
+		distance := levenshtein(word, node.word)
+		if distance == 0 {
+			return
+		}
+		child, ok := node.children[distance]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[distance] = &bkNode{word: word}
+			return
+		}
+		node = child
+	}
+}
+
+// search calls fn for every word within maxDistance of word.
+func (t *bkTree) search(word string, maxDistance int, fn func(word string, distance int)) {
+	if t.root == nil {
+		return
+	}
+
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		distance := levenshtein(word, node.word)
+		if distance <= maxDistance {
+			fn(node.word, distance)
+		}
+		for d, child := range node.children {
+			if d >= distance-maxDistance && d <= distance+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+}
+
+// levenshtein counts the single-character insertions, deletions and
+// substitutions needed to turn a into b.
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,225 @@
+package oshirigame
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Filter levels a room can choose between.
+const (
+	// FilterOff lets everything through.
+	FilterOff = "off"
+	// FilterStandard blocks words that are on the blocklist.
+	FilterStandard = "standard"
+	// FilterStrict also blocks usernames and chat that run a blocked term
+	// together with other words in camel case, or spell one out with
+	// separators in between. Terms are still only matched as whole words, so
+	// "class" or "Scunthorpe" get through.
+	FilterStrict = "strict"
+)
+
+//go:embed blocklist.txt
+var defaultBlocklist string
+
+// Blocklist holds offensive terms that should never be shown or accepted.
+type Blocklist struct {
+	terms map[string]bool
+}
+
+var (
+	blocklistMu sync.RWMutex
+	blocklist   *Blocklist
+)
+
+// NewBlocklist reads one term per line from r. Blank lines and lines starting
+// with # are ignored.
+func NewBlocklist(r io.Reader) (*Blocklist, error) {
+	b := &Blocklist{
+		terms: make(map[string]bool),
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		term := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if term == "" || strings.HasPrefix(term, "#") {
+			continue
+		}
+		b.terms[term] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetBlocklist returns the server-wide blocklist, falling back to the
+// built-in default when none has been loaded.
+func GetBlocklist() *Blocklist {
+	blocklistMu.RLock()
+	b := blocklist
+	blocklistMu.RUnlock()
+	if b != nil {
+		return b
+	}
+
+	blocklistMu.Lock()
+	defer blocklistMu.Unlock()
+	if blocklist == nil {
+		blocklist, _ = NewBlocklist(strings.NewReader(defaultBlocklist))
+	}
+	return blocklist
+}
+
+// LoadBlocklist replaces the built-in blocklist with the terms in path.
+func LoadBlocklist(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	b, err := NewBlocklist(file)
+	if err != nil {
+		return err
+	}
+
+	blocklistMu.Lock()
+	defer blocklistMu.Unlock()
+	blocklist = b
+	return nil
+}
+
+func ValidFilter(level string) bool {
+	return level == FilterOff || level == FilterStandard || level == FilterStrict
+}
+
+// Blocks reports whether text contains anything the level does not allow.
+func (b *Blocklist) Blocks(text string, level string) bool {
+	if level == FilterOff {
+		return false
+	}
+	words := splitWords(text)
+	for _, word := range words {
+		if b.blocksWord(word, level) {
+			return true
+		}
+	}
+	if level == FilterStrict {
+		for _, word := range spelledOut(words) {
+			if b.terms[word] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BlocksWord reports whether a dictionary word is on the blocklist. Words are
+// matched whole at every level, as dictionary words are never camel case or
+// spelled out.
+func (b *Blocklist) BlocksWord(word string, level string) bool {
+	if level == FilterOff {
+		return false
+	}
+	return b.terms[strings.ToLower(word)]
+}
+
+// Censor masks every blocked word in text with asterisks.
+func (b *Blocklist) Censor(text string, level string) string {
+	if level == FilterOff {
+		return text
+	}
+
+	var out strings.Builder
+	var word []rune
+	flush := func() {
+		if b.blocksWord(string(word), level) {
+			out.WriteString(strings.Repeat("*", len(word)))
+		} else {
+			out.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		out.WriteRune(r)
+	}
+	flush()
+	return out.String()
+}
+
+// blocksWord checks a run of letters as it was written, so that strict
+// matching can split it at its case changes.
+func (b *Blocklist) blocksWord(word string, level string) bool {
+	if word == "" {
+		return false
+	}
+	if b.terms[strings.ToLower(word)] {
+		return true
+	}
+	if level == FilterStrict {
+		for _, part := range splitCamelCase(word) {
+			if b.terms[strings.ToLower(part)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitWords splits text into its runs of letters, keeping their case.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// splitCamelCase splits a word before every capital that follows a small
+// letter, so "BigFooBar" becomes "Big", "Foo" and "Bar".
+func splitCamelCase(word string) []string {
+	var parts []string
+	start := 0
+	var previous rune
+	for i, r := range word {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(previous) {
+			parts = append(parts, word[start:i])
+			start = i
+		}
+		previous = r
+	}
+	return append(parts, word[start:])
+}
+
+// spelledOut joins runs of single letters, such as "f o o" or "f.o.o", into
+// the lowercase words they spell.
+func spelledOut(words []string) []string {
+	var spelled []string
+	var run strings.Builder
+	letters := 0
+	flush := func() {
+		if letters > 1 {
+			spelled = append(spelled, run.String())
+		}
+		run.Reset()
+		letters = 0
+	}
+	for _, word := range words {
+		if utf8.RuneCountInString(word) != 1 {
+			flush()
+			continue
+		}
+		run.WriteString(strings.ToLower(word))
+		letters++
+	}
+	flush()
+	return spelled
+}
@@ -0,0 +1,67 @@
+package oshirigame
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const Custom = "custom"
+
+const (
+	// MaxCustomWordListSize is the largest upload accepted, in bytes.
+	MaxCustomWordListSize = 1 << 20
+	// MaxCustomWords is the most distinct words a custom list may hold.
+	MaxCustomWords = 50000
+
+	minCustomWordLength = 2
+	maxCustomWordLength = 45
+)
+
+// customPunctuation is dropped from custom words, so entries like "Mr. Mime"
+// or "x-ray" become something a player can type.
+var customPunctuation = strings.NewReplacer(" ", "", "-", "", "'", "", ".", "", "’", "")
+
+// NewCustomWordList builds a dictionary from a host-provided list with one
+// word per line. Words are lowercased and stripped of spaces and punctuation;
+// entries that still contain anything but letters, or that are too short or
+// too long to play, are skipped. The alphabet is made of the letters the
+// list actually uses.
+func NewCustomWordList(r io.Reader) (*WordList, error) {
+	words := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := customPunctuation.Replace(strings.ToLower(strings.TrimSpace(scanner.Text())))
+		length := utf8.RuneCountInString(word)
+		if length < minCustomWordLength || length > maxCustomWordLength || !isLetters(word) {
+			continue
+		}
+		if !words[word] && len(words) == MaxCustomWords {
+			return nil, fmt.Errorf("word list has more than %d words", MaxCustomWords)
+		}
+		words[word] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return newIndexedWordList(Language{
+		Code:     Custom,
+		Name:     "Custom",
+		Script:   ScriptLatin,
+		Alphabet: alphabetOf(words),
+	}, words)
+}
+
+func isLetters(word string) bool {
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
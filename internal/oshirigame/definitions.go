@@ -0,0 +1,109 @@
+package oshirigame
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxDefinitionLength keeps definitions short enough for the round-over
+// screen, in characters.
+const maxDefinitionLength = 160
+
+// Definitions maps words to a short definition.
+type Definitions struct {
+	entries map[string]string
+}
+
+var (
+	definitionsMu sync.RWMutex
+	definitions   *Definitions
+)
+
+// NewDefinitions reads tab-separated "word<TAB>definition" lines, such as a
+// WordNet gloss export. When a word has several senses only the first one is
+// kept. Blank lines and lines starting with # are ignored.
+func NewDefinitions(r io.Reader) (*Definitions, error) {
+	d := &Definitions{
+		entries: make(map[string]string),
+	}
+
+	line := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		word, definition, ok := strings.Cut(text, "\t")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a word and a definition separated by a tab", line)
+		}
+		word = strings.ToLower(strings.TrimSpace(word))
+		if _, ok := d.entries[word]; ok {
+			continue
+		}
+		d.entries[word] = shorten(strings.TrimSpace(definition))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func shorten(definition string) string {
+	if utf8.RuneCountInString(definition) <= maxDefinitionLength {
+		return definition
+	}
+	runes := []rune(definition)
+	return strings.TrimSpace(string(runes[:maxDefinitionLength-1])) + "…"
+}
+
+// LoadDefinitions makes the definitions in path available to Define.
+func LoadDefinitions(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	d, err := NewDefinitions(file)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", path, err)
+	}
+
+	definitionsMu.Lock()
+	defer definitionsMu.Unlock()
+	definitions = d
+	return nil
+}
+
+// Define looks a word up in the loaded definitions. It reports false when the
+// word is unknown or no definitions have been loaded.
+func Define(word string) (string, bool) {
+	definitionsMu.RLock()
+	d := definitions
+	definitionsMu.RUnlock()
+	if d == nil {
+		return "", false
+	}
+	definition, ok := d.entries[word]
+	return definition, ok
+}
+
+// DefineAll returns the definitions found for words, leaving out the words
+// that have none.
+func DefineAll(words ...string) map[string]string {
+	found := make(map[string]string)
+	for _, word := range words {
+		if definition, ok := Define(word); ok {
+			found[word] = definition
+		}
+	}
+	return found
+}
@@ -0,0 +1,152 @@
+package oshirigame
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Difficulty presets a room can choose between. DifficultyCustom picks pairs
+// by the room's minimum word combinations instead of by rating.
+const (
+	DifficultyCustom = "custom"
+	DifficultyEasy   = "easy"
+	DifficultyNormal = "normal"
+	DifficultyHard   = "hard"
+	DifficultyBrutal = "brutal"
+)
+
+// DifficultyPreset is a band of pair ratings, both ends inclusive.
+type DifficultyPreset struct {
+	Name string `json:"name"`
+	Min  int    `json:"min"`
+	Max  int    `json:"max"`
+}
+
+var difficultyPresets = []DifficultyPreset{
+	{Name: DifficultyEasy, Min: 0, Max: 24},
+	{Name: DifficultyNormal, Min: 25, Max: 49},
+	{Name: DifficultyHard, Min: 50, Max: 74},
+	{Name: DifficultyBrutal, Min: 75, Max: 100},
+}
+
+func GetDifficultyPreset(name string) (DifficultyPreset, bool) {
+	for _, preset := range difficultyPresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return DifficultyPreset{}, false
+}
+
+func ValidDifficulty(name string) bool {
+	_, ok := GetDifficultyPreset(name)
+	return ok || name == DifficultyCustom
+}
+
+// PairRating is how hard an atama and oshiri are to play.
+type PairRating struct {
+	Atama  string `json:"atama"`
+	Oshiri string `json:"oshiri"`
+	Words  int    `json:"words"`
+	// Difficulty ranks the pair against every other pair of the same
+	// lengths, from 0 for the easiest to 100 for the hardest.
+	Difficulty int `json:"difficulty"`
+}
+
+// shortAnswerLength is the most letters a player can type and still count as
+// having an easy answer.
+const shortAnswerLength = 4
+
+// Pairs rates every atama and oshiri of the given lengths that at least one
+// word fits. Pairs with few words, and pairs whose words need many letters
+// typed, are harder. Ratings are computed once per combination of lengths.
+func (wl *WordList) Pairs(atamaLength int, oshiriLength int) []PairRating {
+	atamaLength, oshiriLength = max(atamaLength, 1), max(oshiriLength, 1)
+
+	wl.fragmentsMu.Lock()
+	defer wl.fragmentsMu.Unlock()
+	lengths := [2]int{atamaLength, oshiriLength}
+	if ratings, ok := wl.ratings[lengths]; ok {
+		return ratings
+	}
+
+	type tally struct {
+		words int
+		short int
+	}
+	tallies := make(map[[2]string]*tally)
+	most := 0
+	for word := range wl.words {
+		units := wl.language.Split(word)
+		if len(units) < atamaLength+oshiriLength {
+			continue
+		}
+		pair := [2]string{
+			strings.Join(units[:atamaLength], ""),
+			strings.Join(units[len(units)-oshiriLength:], ""),
+		}
+		t, ok := tallies[pair]
+		if !ok {
+			t = &tally{}
+			tallies[pair] = t
+		}
+		t.words++
+		if len(units)-atamaLength-oshiriLength <= shortAnswerLength {
+			t.short++
+		}
+		most = max(most, t.words)
+	}
+
+	type scored struct {
+		rating   PairRating
+		hardness float64
+	}
+	all := make([]scored, 0, len(tallies))
+	for pair, t := range tallies {
+		scarcity := 1 - math.Log1p(float64(t.words))/math.Log1p(float64(most))
+		length := 1 - float64(t.short)/float64(t.words)
+		all = append(all, scored{
+			rating:   PairRating{Atama: pair[0], Oshiri: pair[1], Words: t.words},
+			hardness: 0.75*scarcity + 0.25*length,
+		})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].hardness != all[j].hardness {
+			return all[i].hardness < all[j].hardness
+		}
+		if all[i].rating.Atama != all[j].rating.Atama {
+			return all[i].rating.Atama < all[j].rating.Atama
+		}
+		return all[i].rating.Oshiri < all[j].rating.Oshiri
+	})
+
+	ratings := make([]PairRating, len(all))
+	for i, s := range all {
+		ratings[i] = s.rating
+		if len(all) > 1 {
+			ratings[i].Difficulty = i * 100 / (len(all) - 1)
+		}
+	}
+	wl.ratings[lengths] = ratings
+	return ratings
+}
+
+// EligiblePairs lists the pairs a room may be given. A preset limits pairs
+// to its band of ratings; DifficultyCustom to pairs with at least minWords
+// words.
+func EligiblePairs(dictionary Dictionary, atamaLength int, oshiriLength int, difficulty string, minWords int) []PairRating {
+	preset, isPreset := GetDifficultyPreset(difficulty)
+
+	var eligible []PairRating
+	for _, pair := range dictionary.Pairs(atamaLength, oshiriLength) {
+		if isPreset && (pair.Difficulty < preset.Min || pair.Difficulty > preset.Max) {
+			continue
+		}
+		if !isPreset && pair.Words < minWords {
+			continue
+		}
+		eligible = append(eligible, pair)
+	}
+	return eligible
+}
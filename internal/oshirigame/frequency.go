@@ -0,0 +1,72 @@
+package oshirigame
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// loadFrequencies reads word frequency data with one "word count" pair per
+// line. Words that are not in the dictionary are ignored. It must only be
+// called before the word list is shared.
+func (wl *WordList) loadFrequencies(r io.Reader) error {
+	wl.wordCounts = make(map[string]int)
+	wl.wordTotal = 0
+
+	line := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf("line %d: expected a word and a count", line)
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil || count < 0 {
+			return fmt.Errorf("line %d: invalid count %q", line, fields[1])
+		}
+
+		word := ToHiragana(strings.ToLower(fields[0]))
+		wl.wordTotal += count
+		if wl.words[word] {
+			wl.wordCounts[word] += count
+		}
+	}
+	return scanner.Err()
+}
+
+// countLetters tallies how often each unit of the alphabet is used across
+// the dictionary.
+func (wl *WordList) countLetters() {
+	wl.letterCounts = make(map[string]int)
+	wl.letterTotal = 0
+	for word := range wl.words {
+		for _, unit := range wl.language.Split(word) {
+			wl.letterCounts[unit]++
+			wl.letterTotal++
+		}
+	}
+}
+
+// WordFrequency returns how many times per million words the word occurs in
+// the frequency data. It reports false when no frequency data was loaded.
+func (wl *WordList) WordFrequency(word string) (float64, bool) {
+	if wl.wordCounts == nil || wl.wordTotal == 0 {
+		return 0, false
+	}
+	return float64(wl.wordCounts[word]) * 1e6 / float64(wl.wordTotal), true
+}
+
+// LetterFrequency returns the share of all letters in the dictionary that
+// are the given letter.
+func (wl *WordList) LetterFrequency(letter string) float64 {
+	if wl.letterTotal == 0 {
+		return 0
+	}
+	return float64(wl.letterCounts[letter]) / float64(wl.letterTotal)
+}
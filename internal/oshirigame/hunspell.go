@@ -0,0 +1,493 @@
+package oshirigame
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Affixes holds the prefix and suffix rules of a Hunspell .aff file, which
+// turn the stems of a .dic file into every word form they stand for.
+type Affixes struct {
+	// encoding decodes the files when SET names a legacy character set;
+	// it is nil for UTF-8.
+	encoding encoding.Encoding
+	flagType string
+	aliases  [][]string
+	prefixes map[string]*affixClass
+	suffixes map[string]*affixClass
+	// Stems carrying one of these flags are not words on their own.
+	needAffix      string
+	forbidden      string
+	onlyInCompound string
+	// inflectional decides for suffixes the .aff file doesn't describe
+	// whether they inflect base into word. Without it they are taken to
+	// make new words.
+	inflectional func(word string, base string) bool
+}
+
+type affixClass struct {
+	cross bool
+	rules []affixRule
+}
+
+type affixRule struct {
+	strip     string
+	add       string
+	condition *regexp.Regexp
+	flags     []string
+	// morph is "is" for an inflectional suffix and "ds" for a derivational
+	// one, when the rule carries a morphological description.
+	morph string
+}
+
+// ParseAffixes reads a Hunspell .aff file. Only the directives needed to
+// expand word forms are understood; the rest, such as compounding and
+// suggestion settings, are skipped.
+func ParseAffixes(r io.Reader) (*Affixes, error) {
+	a := &Affixes{
+		prefixes: make(map[string]*affixClass),
+		suffixes: make(map[string]*affixClass),
+	}
+
+	line := 0
+	seenAliasCount := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line++
+		text, err := a.decode(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "SET":
+			if len(fields) > 1 {
+				a.encoding, err = charset(fields[1])
+			}
+		case "FLAG":
+			if len(fields) > 1 {
+				a.flagType = fields[1]
+			}
+		case "AF":
+			// The first AF line only holds the number of aliases.
+			if len(fields) > 1 {
+				if seenAliasCount {
+					a.aliases = append(a.aliases, a.splitFlags(fields[1]))
+				}
+				seenAliasCount = true
+			}
+		case "NEEDAFFIX", "PSEUDOROOT":
+			a.needAffix, err = a.singleFlag(fields)
+		case "FORBIDDENWORD":
+			a.forbidden, err = a.singleFlag(fields)
+		case "ONLYINCOMPOUND":
+			a.onlyInCompound, err = a.singleFlag(fields)
+		case "PFX", "SFX":
+			err = a.parseAffix(fields)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// charsets are the SET values Hunspell dictionaries use besides UTF-8.
+var charsets = map[string]encoding.Encoding{
+	"ISO8859-1":        charmap.ISO8859_1,
+	"ISO8859-2":        charmap.ISO8859_2,
+	"ISO8859-3":        charmap.ISO8859_3,
+	"ISO8859-4":        charmap.ISO8859_4,
+	"ISO8859-5":        charmap.ISO8859_5,
+	"ISO8859-6":        charmap.ISO8859_6,
+	"ISO8859-7":        charmap.ISO8859_7,
+	"ISO8859-8":        charmap.ISO8859_8,
+	"ISO8859-9":        charmap.ISO8859_9,
+	"ISO8859-10":       charmap.ISO8859_10,
+	"ISO8859-13":       charmap.ISO8859_13,
+	"ISO8859-14":       charmap.ISO8859_14,
+	"ISO8859-15":       charmap.ISO8859_15,
+	"KOI8-R":           charmap.KOI8R,
+	"KOI8-U":           charmap.KOI8U,
+	"microsoft-cp1251": charmap.Windows1251,
+}
+
+func charset(name string) (encoding.Encoding, error) {
+	if strings.EqualFold(name, "UTF-8") {
+		return nil, nil
+	}
+	for n, e := range charsets {
+		if strings.EqualFold(n, name) {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported character set %q", name)
+}
+
+// decode turns a line of the .aff or .dic file into UTF-8.
+func (a *Affixes) decode(line []byte) (string, error) {
+	if a.encoding == nil {
+		return string(line), nil
+	}
+	decoded, err := a.encoding.NewDecoder().Bytes(line)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+func (a *Affixes) singleFlag(fields []string) (string, error) {
+	if len(fields) < 2 {
+		return "", fmt.Errorf("%s needs a flag", fields[0])
+	}
+	return fields[1], nil
+}
+
+// parseAffix handles both the "PFX A Y 2" header and the rule lines under it.
+func (a *Affixes) parseAffix(fields []string) error {
+	if len(fields) < 4 {
+		return fmt.Errorf("malformed %s line", fields[0])
+	}
+
+	classes := a.suffixes
+	if fields[0] == "PFX" {
+		classes = a.prefixes
+	}
+
+	flag := fields[1]
+	class, ok := classes[flag]
+	if !ok {
+		classes[flag] = &affixClass{cross: fields[2] == "Y"}
+		return nil
+	}
+
+	strip := fields[2]
+	if strip == "0" {
+		strip = ""
+	}
+	add, flags, _ := strings.Cut(fields[3], "/")
+	if add == "0" {
+		add = ""
+	}
+	condition := "."
+	if len(fields) > 4 {
+		condition = fields[4]
+	}
+
+	pattern := conditionPattern(condition)
+	if fields[0] == "PFX" {
+		pattern = "^" + pattern
+	} else {
+		pattern = pattern + "$"
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+
+	rule := affixRule{
+		strip:     strip,
+		add:       add,
+		condition: compiled,
+	}
+	if len(fields) > 5 {
+		for _, field := range fields[5:] {
+			if strings.HasPrefix(field, "is:") || strings.HasPrefix(field, "ds:") {
+				rule.morph = field[:2]
+				break
+			}
+		}
+	}
+	if flags != "" {
+		rule.flags = a.parseFlags(flags)
+	}
+	class.rules = append(class.rules, rule)
+	return nil
+}
+
+// conditionPattern turns a Hunspell condition, which only knows ".", "[...]"
+// and "[^...]", into a regular expression.
+func conditionPattern(condition string) string {
+	var b strings.Builder
+	inClass := false
+	for _, r := range condition {
+		switch {
+		case r == '[' && !inClass:
+			inClass = true
+			b.WriteRune(r)
+		case r == ']' && inClass:
+			inClass = false
+			b.WriteRune(r)
+		case r == '.' && !inClass:
+			b.WriteRune(r)
+		case inClass && r == '^':
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// parseFlags splits a flag field according to the FLAG directive, resolving
+// AF aliases when the file uses them.
+func (a *Affixes) parseFlags(s string) []string {
+	if len(a.aliases) > 0 {
+		if i, err := strconv.Atoi(s); err == nil && i >= 1 && i <= len(a.aliases) {
+			return a.aliases[i-1]
+		}
+	}
+	return a.splitFlags(s)
+}
+
+func (a *Affixes) splitFlags(s string) []string {
+	switch a.flagType {
+	case "long":
+		var flags []string
+		runes := []rune(s)
+		for i := 0; i+1 < len(runes); i += 2 {
+			flags = append(flags, string(runes[i:i+2]))
+		}
+		return flags
+	case "num":
+		return strings.Split(s, ",")
+	default:
+		return strings.Split(s, "")
+	}
+}
+
+// Expand reads a Hunspell .dic file and calls fn with every word form it
+// describes together with its lemma. The .dic file is read in the character
+// set the .aff file names. Prefixes and suffixes are combined when
+// both allow cross products, and suffixes may carry one further suffix
+// through continuation flags. Only inflectional suffixes lead back to a
+// lemma; forms made with a prefix or a derivational suffix are their own.
+func (a *Affixes) Expand(dic io.Reader, fn func(word string, lemma string)) error {
+	scanner := bufio.NewScanner(dic)
+	scanner.Buffer(nil, 1<<20)
+	first := true
+	for scanner.Scan() {
+		text, err := a.decode(scanner.Bytes())
+		if err != nil {
+			return err
+		}
+		text = strings.TrimSpace(text)
+		if first {
+			first = false
+			// The first line is the approximate number of entries.
+			if _, err := strconv.Atoi(text); err == nil {
+				continue
+			}
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		// Morphological fields follow the entry after whitespace.
+		entry := strings.Fields(text)[0]
+		stem, flagField, _ := strings.Cut(entry, "/")
+		var flags []string
+		if flagField != "" {
+			flags = a.parseFlags(flagField)
+		}
+		a.expandStem(stem, flags, fn)
+	}
+	return scanner.Err()
+}
+
+// inflects reports whether a suffix rule turned base into an inflected form
+// rather than a new word.
+func (a *Affixes) inflects(rule affixRule, word string, base string) bool {
+	switch rule.morph {
+	case "is":
+		return true
+	case "ds":
+		return false
+	}
+	return a.inflectional != nil && a.inflectional(word, base)
+}
+
+func (a *Affixes) expandStem(stem string, flags []string, fn func(word string, lemma string)) {
+	if hasFlag(flags, a.forbidden) {
+		return
+	}
+	if !hasFlag(flags, a.needAffix) && !hasFlag(flags, a.onlyInCompound) {
+		fn(stem, stem)
+	}
+
+	type suffixed struct {
+		word      string
+		inflected bool
+	}
+	var crossable []suffixed
+
+	for _, flag := range flags {
+		class, ok := a.suffixes[flag]
+		if !ok {
+			continue
+		}
+		for _, rule := range class.rules {
+			word, ok := rule.applySuffix(stem)
+			if !ok {
+				continue
+			}
+			inflected := a.inflects(rule, word, stem)
+			lemma := word
+			if inflected {
+				lemma = stem
+			}
+			if !hasFlag(rule.flags, a.needAffix) {
+				fn(word, lemma)
+				if class.cross {
+					crossable = append(crossable, suffixed{word: word, inflected: inflected})
+				}
+			}
+			// Twofold suffixes: the new form may take one more suffix.
+			for _, next := range rule.flags {
+				nextClass, ok := a.suffixes[next]
+				if !ok {
+					continue
+				}
+				for _, nextRule := range nextClass.rules {
+					twice, ok := nextRule.applySuffix(word)
+					if !ok {
+						continue
+					}
+					if a.inflects(nextRule, twice, word) {
+						fn(twice, lemma)
+					} else {
+						fn(twice, twice)
+					}
+				}
+			}
+		}
+	}
+
+	for _, flag := range flags {
+		class, ok := a.prefixes[flag]
+		if !ok {
+			continue
+		}
+		for _, rule := range class.rules {
+			prefixed, ok := rule.applyPrefix(stem)
+			if ok && !hasFlag(rule.flags, a.needAffix) {
+				fn(prefixed, prefixed)
+			}
+			if !class.cross {
+				continue
+			}
+			// A prefixed form inflects like its stem: "reworked" is a form
+			// of "rework".
+			for _, s := range crossable {
+				word, ok := rule.applyPrefix(s.word)
+				if !ok {
+					continue
+				}
+				if s.inflected && prefixed != "" {
+					fn(word, prefixed)
+				} else {
+					fn(word, word)
+				}
+			}
+		}
+	}
+}
+
+func (rule affixRule) applySuffix(word string) (string, bool) {
+	if !strings.HasSuffix(word, rule.strip) || !rule.condition.MatchString(word) {
+		return "", false
+	}
+	return word[:len(word)-len(rule.strip)] + rule.add, true
+}
+
+func (rule affixRule) applyPrefix(word string) (string, bool) {
+	if !strings.HasPrefix(word, rule.strip) || !rule.condition.MatchString(word) {
+		return "", false
+	}
+	return rule.add + word[len(rule.strip):], true
+}
+
+func hasFlag(flags []string, flag string) bool {
+	if flag == "" {
+		return false
+	}
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// NewHunspellWordList expands a Hunspell dictionary into a word list. Forms
+// are lowercased and, like NewWordList, forms with letters outside the
+// language's alphabet are skipped. A language without an alphabet gets one
+// made of the letters its words use. Forms made with inflectional suffixes
+// get their lemmas from the stems.
+func NewHunspellWordList(language Language, dic io.Reader, aff io.Reader) (*WordList, error) {
+	affixes, err := ParseAffixes(aff)
+	if err != nil {
+		return nil, fmt.Errorf("reading affixes: %w", err)
+	}
+
+	// English .aff files rarely describe their affixes, so regular
+	// inflections are recognised by their spelling instead.
+	if language.Code == English {
+		affixes.inflectional = isEnglishInflection
+	}
+
+	alphabet := alphabetSet(language.Alphabet)
+	words := make(map[string]bool)
+	lemmas := make(map[string]string)
+	err = affixes.Expand(dic, func(word string, lemma string) {
+		word = strings.ToLower(word)
+		lemma = strings.ToLower(lemma)
+		if utf8.RuneCountInString(word) < 2 {
+			return
+		}
+		if len(alphabet) > 0 && !inAlphabet(word, alphabet) {
+			return
+		}
+		if len(alphabet) == 0 && !isLetters(word) {
+			return
+		}
+		words[word] = true
+		// A form that is also a word of its own, such as a stem in the
+		// .dic file, isn't taken as an inflection.
+		if previous, ok := lemmas[word]; !ok || (previous != word && lemma == word) {
+			lemmas[word] = lemma
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading dictionary: %w", err)
+	}
+
+	if len(language.Alphabet) == 0 {
+		language.Alphabet = alphabetOf(words)
+	}
+	wl, err := newIndexedWordList(language, words)
+	if err != nil {
+		return nil, err
+	}
+
+	for word, lemma := range lemmas {
+		wl.addLemma(word, lemma)
+	}
+	return wl, nil
+}
@@ -0,0 +1,245 @@
+package oshirigame
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func openTestdata(t *testing.T, name string) *os.File {
+	t.Helper()
+	file, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+// expand runs Expand and returns the forms it produced with their lemmas.
+func expand(t *testing.T, aff string, dic string) map[string]string {
+	t.Helper()
+	affixes, err := ParseAffixes(strings.NewReader(aff))
+	if err != nil {
+		t.Fatalf("ParseAffixes: %v", err)
+	}
+	forms := make(map[string]string)
+	err = affixes.Expand(strings.NewReader(dic), func(word string, lemma string) {
+		forms[word] = lemma
+	})
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	return forms
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestExpandFixture(t *testing.T) {
+	affixes, err := ParseAffixes(openTestdata(t, "xx.aff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	err = affixes.Expand(openTestdata(t, "xx.dic"), func(word string, lemma string) {
+		words = append(words, word)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(words)
+
+	want := []string{"carried", "carry", "reworked", "rework", "reworks", "work", "worked", "works", "worksly"}
+	sort.Strings(want)
+	if strings.Join(words, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", words, want)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name string
+		aff  string
+		dic  string
+		want []string
+	}{
+		{
+			name: "suffix condition and strip",
+			aff:  "SFX A Y 2\nSFX A 0 ed [^y]\nSFX A y ied [^aeiou]y\n",
+			dic:  "2\nwalk/A\ncarry/A\n",
+			want: []string{"carried", "carry", "walk", "walked"},
+		},
+		{
+			name: "cross product",
+			aff:  "PFX P Y 1\nPFX P 0 un .\nSFX S Y 1\nSFX S 0 s .\n",
+			dic:  "1\ndo/PS\n",
+			want: []string{"do", "dos", "undo", "undos"},
+		},
+		{
+			name: "no cross product",
+			aff:  "PFX P N 1\nPFX P 0 un .\nSFX S Y 1\nSFX S 0 s .\n",
+			dic:  "1\ndo/PS\n",
+			want: []string{"do", "dos", "undo"},
+		},
+		{
+			name: "continuation flag",
+			aff:  "SFX A Y 1\nSFX A 0 ful/B .\nSFX B Y 1\nSFX B 0 ly .\n",
+			dic:  "1\nhope/A\n",
+			want: []string{"hope", "hopeful", "hopefully"},
+		},
+		{
+			name: "needaffix stem",
+			aff:  "NEEDAFFIX X\nSFX A Y 1\nSFX A 0 ing .\n",
+			dic:  "1\nbring/AX\n",
+			want: []string{"bringing"},
+		},
+		{
+			name: "needaffix on a continued suffix",
+			aff:  "NEEDAFFIX X\nSFX A Y 1\nSFX A 0 er/XB .\nSFX B Y 1\nSFX B 0 s .\n",
+			dic:  "1\nwalk/A\n",
+			want: []string{"walk", "walkers"},
+		},
+		{
+			name: "forbidden word",
+			aff:  "FORBIDDENWORD F\nSFX A Y 1\nSFX A 0 s .\n",
+			dic:  "2\ncat/A\ndog/AF\n",
+			want: []string{"cat", "cats"},
+		},
+		{
+			name: "long flags",
+			aff:  "FLAG long\nSFX Aa Y 1\nSFX Aa 0 s .\nSFX Bb Y 1\nSFX Bb 0 ed .\n",
+			dic:  "1\njump/AaBb\n",
+			want: []string{"jump", "jumped", "jumps"},
+		},
+		{
+			name: "numeric flags",
+			aff:  "FLAG num\nSFX 10 Y 1\nSFX 10 0 s .\nSFX 200 Y 1\nSFX 200 0 ed .\n",
+			dic:  "1\njump/10,200\n",
+			want: []string{"jump", "jumped", "jumps"},
+		},
+		{
+			name: "flag aliases",
+			aff:  "AF 1\nAF AB\nSFX A Y 1\nSFX A 0 s .\nSFX B Y 1\nSFX B 0 ed .\n",
+			dic:  "1\njump/1\n",
+			want: []string{"jump", "jumped", "jumps"},
+		},
+		{
+			name: "morphological fields",
+			aff:  "SFX A Y 1\nSFX A 0 s .\n",
+			dic:  "1\ncat/A\tpo:noun\n",
+			want: []string{"cat", "cats"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sortedKeys(expand(t, tt.aff, tt.dic))
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandCharset(t *testing.T) {
+	// "båt" and "båtar" in ISO 8859-1.
+	aff := "SET ISO8859-1\nSFX A Y 1\nSFX A 0 ar .\n"
+	dic := "1\nb\xe5t/A\n"
+	got := sortedKeys(expand(t, aff, dic))
+	want := []string{"båt", "båtar"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := ParseAffixes(strings.NewReader("SET EBCDIC\n")); err == nil {
+		t.Error("expected an unsupported character set to fail")
+	}
+}
+
+func TestConditionPattern(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      string
+	}{
+		{".", "."},
+		{"y", "y"},
+		{"[^aeiou]y", "[^aeiou]y"},
+		{"[ey]", "[ey]"},
+		{"a.b", "a.b"},
+		{"e+", `e\+`},
+		{"[.]", `[\.]`},
+		{"ä", "ä"},
+	}
+
+	for _, tt := range tests {
+		if got := conditionPattern(tt.condition); got != tt.want {
+			t.Errorf("conditionPattern(%q) = %q, want %q", tt.condition, got, tt.want)
+		}
+	}
+}
+
+func TestHunspellLemmas(t *testing.T) {
+	aff := strings.Join([]string{
+		"PFX R Y 1",
+		"PFX R 0 re .",
+		"SFX S Y 1",
+		"SFX S 0 s . is:plural",
+		"SFX N Y 1",
+		"SFX N 0 ness . ds:ness",
+		"SFX L Y 1",
+		"SFX L 0 ly/S .",
+		"SFX E Y 1",
+		"SFX E 0 ed .",
+	}, "\n")
+	dic := strings.Join([]string{
+		"6",
+		"kind/NS",
+		"word/RS",
+		"friend/L",
+		"play/E",
+		"played",
+		"walk/E",
+	}, "\n")
+	language := Language{Code: "xx", Name: "xx", Script: ScriptLatin}
+
+	tests := []struct {
+		name  string
+		code  string
+		word  string
+		lemma string
+		ok    bool
+	}{
+		{"inflectional suffix", "xx", "kinds", "kind", true},
+		{"derivational suffix", "xx", "kindness", "", false},
+		{"prefixed stem", "xx", "reword", "", false},
+		{"prefixed inflection", "xx", "rewords", "reword", true},
+		{"undescribed suffix", "xx", "friendly", "", false},
+		{"inflection of a continued suffix", "xx", "friendlys", "friendly", true},
+		{"undescribed suffix outside English", "xx", "walked", "", false},
+		{"english inflection by spelling", English, "walked", "walk", true},
+		{"form listed as a stem", English, "played", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang := language
+			lang.Code = tt.code
+			wl, err := NewHunspellWordList(lang, strings.NewReader(dic), strings.NewReader(aff))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lemma, ok := wl.Lemma(tt.word)
+			if ok != tt.ok || lemma != tt.lemma {
+				t.Errorf("Lemma(%q) = %q, %v, want %q, %v", tt.word, lemma, ok, tt.lemma, tt.ok)
+			}
+		})
+	}
+}
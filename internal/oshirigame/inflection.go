@@ -0,0 +1,181 @@
+package oshirigame
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Inflection policies a room can choose between.
+const (
+	// InflectionAllow scores inflected words like any other word.
+	InflectionAllow = "allow"
+	// InflectionBan rejects inflected words.
+	InflectionBan = "ban"
+	// InflectionLemma accepts inflected words but only scores their lemma.
+	InflectionLemma = "lemma"
+)
+
+// minLemmaLength keeps derived lemmas from matching words like "bed" to "b".
+const minLemmaLength = 3
+
+func ValidInflection(policy string) bool {
+	return policy == InflectionAllow || policy == InflectionBan || policy == InflectionLemma
+}
+
+// Lemma returns the base form of an inflected word. It reports false for
+// words that are not known to be inflected.
+func (wl *WordList) Lemma(word string) (string, bool) {
+	lemma, ok := wl.lemmas[word]
+	return lemma, ok
+}
+
+func (wl *WordList) addLemma(word string, lemma string) {
+	if word == lemma || !wl.words[word] || !wl.words[lemma] {
+		return
+	}
+	if wl.lemmas == nil {
+		wl.lemmas = make(map[string]string)
+	}
+	wl.lemmas[word] = lemma
+}
+
+// loadLemmas reads a lemma mapping with one "form lemma" pair per line,
+// replacing any lemmas the word list already had. It must only be called
+// before the word list is shared.
+func (wl *WordList) loadLemmas(r io.Reader) error {
+	wl.lemmas = nil
+
+	line := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf("line %d: expected a word form and its lemma", line)
+		}
+		wl.addLemma(ToHiragana(strings.ToLower(fields[0])), ToHiragana(strings.ToLower(fields[1])))
+	}
+	return scanner.Err()
+}
+
+// englishLexicalized holds words that look inflected but are words of their
+// own, where the shorter word they contain is unrelated or only distantly so.
+var englishLexicalized = map[string]bool{
+	"awning": true, "ceiling": true, "crooked": true, "dogged": true,
+	"during": true, "economics": true, "evening": true, "jagged": true,
+	"learned": true, "lens": true, "mathematics": true, "morning": true,
+	"naked": true, "news": true, "nothing": true, "physics": true,
+	"politics": true, "pudding": true, "ragged": true, "rugged": true,
+	"sacred": true, "series": true, "shed": true, "species": true,
+	"thing": true, "wedding": true, "wicked": true, "wretched": true,
+}
+
+// deriveEnglishLemmas maps English plurals, past tenses and -ing forms to
+// their base word when that base word is in the dictionary. It is only used
+// when no lemma mapping ships with the word list, so it errs on the side of
+// leaving words alone: -ed and -ing words only count as inflections when
+// the dictionary also has the base word's other verb form.
+func (wl *WordList) deriveEnglishLemmas() {
+	for word := range wl.words {
+		if englishLexicalized[word] {
+			continue
+		}
+		for _, lemma := range englishLemmaCandidates(word) {
+			if len(lemma) >= minLemmaLength && wl.words[lemma] && wl.hasEnglishVerbForms(word, lemma) {
+				wl.addLemma(word, lemma)
+				break
+			}
+		}
+	}
+}
+
+// hasEnglishVerbForms reports whether a -ed or -ing word's lemma also has the
+// other of the two forms in the dictionary, which keeps words like "thing"
+// from passing as forms of "the". Other words need no such evidence.
+func (wl *WordList) hasEnglishVerbForms(word string, lemma string) bool {
+	var others []string
+	switch {
+	case strings.HasSuffix(word, "ing"):
+		others = englishPastForms(lemma)
+	case strings.HasSuffix(word, "ed"):
+		others = englishParticipleForms(lemma)
+	default:
+		return true
+	}
+	for _, other := range others {
+		if wl.words[other] {
+			return true
+		}
+	}
+	return false
+}
+
+// englishPastForms lists the regular past tense spellings of a verb.
+func englishPastForms(lemma string) []string {
+	last := lemma[len(lemma)-1:]
+	forms := []string{lemma + "ed", lemma + last + "ed"}
+	if strings.HasSuffix(lemma, "e") {
+		forms = append(forms, lemma+"d")
+	}
+	if strings.HasSuffix(lemma, "y") {
+		forms = append(forms, strings.TrimSuffix(lemma, "y")+"ied")
+	}
+	return forms
+}
+
+// englishParticipleForms lists the regular -ing spellings of a verb.
+func englishParticipleForms(lemma string) []string {
+	last := lemma[len(lemma)-1:]
+	forms := []string{lemma + "ing", lemma + last + "ing"}
+	if strings.HasSuffix(lemma, "e") {
+		forms = append(forms, strings.TrimSuffix(lemma, "e")+"ing")
+	}
+	return forms
+}
+
+// isEnglishInflection reports whether word is a regular plural, past tense
+// or -ing form of lemma.
+func isEnglishInflection(word string, lemma string) bool {
+	for _, candidate := range englishLemmaCandidates(word) {
+		if candidate == lemma {
+			return true
+		}
+	}
+	return false
+}
+
+// englishLemmaCandidates lists the base forms word could be inflected from,
+// most likely first.
+func englishLemmaCandidates(word string) []string {
+	n := len(word)
+	doubled := func(suffix int) bool {
+		return n > suffix+1 && word[n-suffix-1] == word[n-suffix-2]
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies"), strings.HasSuffix(word, "ied"):
+		return []string{word[:n-3] + "y"}
+	case strings.HasSuffix(word, "es"):
+		return []string{word[:n-2], word[:n-1]}
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return []string{word[:n-1]}
+	case strings.HasSuffix(word, "ed"):
+		candidates := []string{word[:n-2], word[:n-1]}
+		if doubled(2) {
+			candidates = append(candidates, word[:n-3])
+		}
+		return candidates
+	case strings.HasSuffix(word, "ing"):
+		candidates := []string{word[:n-3], word[:n-3] + "e"}
+		if doubled(3) {
+			candidates = append(candidates, word[:n-4])
+		}
+		return candidates
+	}
+	return nil
+}
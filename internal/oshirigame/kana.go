@@ -0,0 +1,271 @@
+package oshirigame
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Morae are the plain hiragana units atama and oshiri are drawn from in kana
+// mode. ん is left out because no word may start or end with it.
+var Morae = []string{
+	"あ", "い", "う", "え", "お",
+	"か", "き", "く", "け", "こ",
+	"さ", "し", "す", "せ", "そ",
+	"た", "ち", "つ", "て", "と",
+	"な", "に", "ぬ", "ね", "の",
+	"は", "ひ", "ふ", "へ", "ほ",
+	"ま", "み", "む", "め", "も",
+	"や", "ゆ", "よ",
+	"ら", "り", "る", "れ", "ろ",
+	"わ",
+	"が", "ぎ", "ぐ", "げ", "ご",
+	"ざ", "じ", "ず", "ぜ", "ぞ",
+	"だ", "ぢ", "づ", "で", "ど",
+	"ば", "び", "ぶ", "べ", "ぼ",
+	"ぱ", "ぴ", "ぷ", "ぺ", "ぽ",
+}
+
+// Yoon maps each i-column kana to the yōon morae written with it.
+var Yoon = map[string][]string{
+	"き": {"きゃ", "きゅ", "きょ"},
+	"し": {"しゃ", "しゅ", "しょ"},
+	"ち": {"ちゃ", "ちゅ", "ちょ"},
+	"に": {"にゃ", "にゅ", "にょ"},
+	"ひ": {"ひゃ", "ひゅ", "ひょ"},
+	"み": {"みゃ", "みゅ", "みょ"},
+	"り": {"りゃ", "りゅ", "りょ"},
+	"ぎ": {"ぎゃ", "ぎゅ", "ぎょ"},
+	"じ": {"じゃ", "じゅ", "じょ"},
+	"び": {"びゃ", "びゅ", "びょ"},
+	"ぴ": {"ぴゃ", "ぴゅ", "ぴょ"},
+}
+
+const Japanese = "ja"
+
+var LanguageJapanese = Language{
+	Code:     Japanese,
+	Name:     "日本語",
+	Script:   ScriptKana,
+	Alphabet: allMorae(),
+}
+
+func allMorae() []string {
+	morae := append([]string(nil), Morae...)
+	for _, mora := range Morae {
+		morae = append(morae, Yoon[mora]...)
+	}
+	return morae
+}
+
+// KanaWordList is a hiragana dictionary for shiritori. Players type romaji,
+// which is converted to kana before any lookup.
+type KanaWordList struct {
+	*WordList
+}
+
+// NewKanaWordList reads one word per line from r. Katakana is folded to
+// hiragana, and words ending in ん are dropped since they lose in shiritori.
+func NewKanaWordList(r io.Reader) (*KanaWordList, error) {
+	words := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := ToHiragana(strings.TrimSpace(scanner.Text()))
+		if word == "" || !isHiragana(word) || strings.HasSuffix(word, "ん") {
+			continue
+		}
+		words[word] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	wl, err := newIndexedWordList(LanguageJapanese, words)
+	if err != nil {
+		return nil, err
+	}
+	return &KanaWordList{WordList: wl}, nil
+}
+
+func (kl *KanaWordList) Normalize(input string) string {
+	return RomajiToKana(input)
+}
+
+func isHiragana(word string) bool {
+	for _, r := range word {
+		if !unicode.Is(unicode.Hiragana, r) && r != 'ー' {
+			return false
+		}
+	}
+	return true
+}
+
+// ToHiragana folds katakana to the matching hiragana and leaves everything
+// else untouched.
+func ToHiragana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ァ' && r <= 'ヶ' {
+			return r - 'ァ' + 'ぁ'
+		}
+		return r
+	}, s)
+}
+
+// MoraCount counts the morae of a kana word. Small ゃ, ゅ, ょ and the small
+// vowels attach to the kana before them.
+func MoraCount(word string) int {
+	count := 0
+	for _, r := range word {
+		if !isSmallKana(r) {
+			count++
+		}
+	}
+	return count
+}
+
+// SplitMorae splits a kana word into morae, keeping small kana with the kana
+// before them.
+func SplitMorae(word string) []string {
+	morae := make([]string, 0, len(word))
+	for _, r := range word {
+		if len(morae) > 0 && isSmallKana(r) {
+			morae[len(morae)-1] += string(r)
+			continue
+		}
+		morae = append(morae, string(r))
+	}
+	return morae
+}
+
+func isSmallKana(r rune) bool {
+	return strings.ContainsRune("ゃゅょぁぃぅぇぉゎ", r)
+}
+
+var romaji = map[string]string{
+	"a": "あ", "i": "い", "u": "う", "e": "え", "o": "お",
+	"ka": "か", "ki": "き", "ku": "く", "ke": "け", "ko": "こ",
+	"sa": "さ", "si": "し", "shi": "し", "su": "す", "se": "せ", "so": "そ",
+	"ta": "た", "ti": "ち", "chi": "ち", "tu": "つ", "tsu": "つ", "te": "て", "to": "と",
+	"na": "な", "ni": "に", "nu": "ぬ", "ne": "ね", "no": "の",
+	"ha": "は", "hi": "ひ", "hu": "ふ", "fu": "ふ", "he": "へ", "ho": "ほ",
+	"ma": "ま", "mi": "み", "mu": "む", "me": "め", "mo": "も",
+	"ya": "や", "yu": "ゆ", "yo": "よ",
+	"ra": "ら", "ri": "り", "ru": "る", "re": "れ", "ro": "ろ",
+	"wa": "わ", "wi": "ゐ", "we": "ゑ", "wo": "を",
+	"ga": "が", "gi": "ぎ", "gu": "ぐ", "ge": "げ", "go": "ご",
+	"za": "ざ", "zi": "じ", "ji": "じ", "zu": "ず", "ze": "ぜ", "zo": "ぞ",
+	"da": "だ", "di": "ぢ", "du": "づ", "de": "で", "do": "ど",
+	"ba": "ば", "bi": "び", "bu": "ぶ", "be": "べ", "bo": "ぼ",
+	"pa": "ぱ", "pi": "ぴ", "pu": "ぷ", "pe": "ぺ", "po": "ぽ",
+	"vu": "ゔ",
+
+	"kya": "きゃ", "kyu": "きゅ", "kyo": "きょ",
+	"sya": "しゃ", "syu": "しゅ", "syo": "しょ",
+	"sha": "しゃ", "shu": "しゅ", "sho": "しょ", "she": "しぇ",
+	"tya": "ちゃ", "tyu": "ちゅ", "tyo": "ちょ",
+	"cha": "ちゃ", "chu": "ちゅ", "cho": "ちょ", "che": "ちぇ",
+	"nya": "にゃ", "nyu": "にゅ", "nyo": "にょ",
+	"hya": "ひゃ", "hyu": "ひゅ", "hyo": "ひょ",
+	"mya": "みゃ", "myu": "みゅ", "myo": "みょ",
+	"rya": "りゃ", "ryu": "りゅ", "ryo": "りょ",
+	"gya": "ぎゃ", "gyu": "ぎゅ", "gyo": "ぎょ",
+	"ja": "じゃ", "ju": "じゅ", "jo": "じょ", "je": "じぇ",
+	"jya": "じゃ", "jyu": "じゅ", "jyo": "じょ",
+	"zya": "じゃ", "zyu": "じゅ", "zyo": "じょ",
+	"dya": "ぢゃ", "dyu": "ぢゅ", "dyo": "ぢょ",
+	"bya": "びゃ", "byu": "びゅ", "byo": "びょ",
+	"pya": "ぴゃ", "pyu": "ぴゅ", "pyo": "ぴょ",
+	"fa": "ふぁ", "fi": "ふぃ", "fe": "ふぇ", "fo": "ふぉ",
+	"thi": "てぃ", "dhi": "でぃ",
+
+	"xa": "ぁ", "xi": "ぃ", "xu": "ぅ", "xe": "ぇ", "xo": "ぉ",
+	"la": "ぁ", "li": "ぃ", "lu": "ぅ", "le": "ぇ", "lo": "ぉ",
+	"xya": "ゃ", "xyu": "ゅ", "xyo": "ょ",
+	"lya": "ゃ", "lyu": "ゅ", "lyo": "ょ",
+	"xtu": "っ", "ltu": "っ", "xtsu": "っ", "ltsu": "っ",
+	"xwa": "ゎ", "lwa": "ゎ",
+
+	"-": "ー",
+}
+
+// Long vowels written with a macron or circumflex are spelled out the way
+// they appear in a hiragana dictionary.
+var longVowels = strings.NewReplacer(
+	"ā", "aa", "ī", "ii", "ū", "uu", "ē", "ee", "ō", "ou",
+	"â", "aa", "î", "ii", "û", "uu", "ê", "ee", "ô", "ou",
+)
+
+// RomajiToKana converts Hepburn or kunrei romaji to hiragana. Kana in the
+// input is kept, with katakana folded to hiragana, and romaji that does not
+// form a complete syllable yet is left as typed.
+func RomajiToKana(input string) string {
+	s := longVowels.Replace(ToHiragana(strings.ToLower(input)))
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteString(s[i : i+size])
+			i += size
+			continue
+		}
+
+		var next byte
+		if i+1 < len(s) {
+			next = s[i+1]
+		}
+
+		// ん is written n before a consonant or at the end of the word, nn
+		// or n' anywhere. A single n before a vowel or y starts a syllable.
+		if c == 'n' && !isVowel(next) && next != 'y' {
+			b.WriteString("ん")
+			i++
+			if next == '\'' || (next == 'n' && !startsSyllable(s[i+1:])) {
+				i++
+			}
+			continue
+		}
+
+		// A doubled consonant, or t before ch, is a small っ.
+		if isConsonant(c) && (next == c || (c == 't' && strings.HasPrefix(s[i+1:], "ch"))) {
+			b.WriteString("っ")
+			i++
+			continue
+		}
+
+		matched := false
+		for size := 4; size > 0; size-- {
+			if i+size > len(s) {
+				continue
+			}
+			if kana, ok := romaji[s[i:i+size]]; ok {
+				b.WriteString(kana)
+				i += size
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aiueo", c) >= 0
+}
+
+func isConsonant(c byte) bool {
+	return c >= 'a' && c <= 'z' && !isVowel(c) && c != 'n'
+}
+
+// startsSyllable reports whether s begins with a vowel or y, meaning an n in
+// front of it belongs to the next syllable.
+func startsSyllable(s string) bool {
+	return s != "" && (isVowel(s[0]) || s[0] == 'y')
+}
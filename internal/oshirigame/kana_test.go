@@ -0,0 +1,96 @@
+package oshirigame
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRomajiToKana(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"sakura", "さくら"},
+		{"shiritori", "しりとり"},
+		{"siritori", "しりとり"},
+		{"tsukue", "つくえ"},
+		// Yōon
+		{"kyou", "きょう"},
+		{"shashin", "しゃしん"},
+		{"jinja", "じんじゃ"},
+		{"ryokou", "りょこう"},
+		// Sokuon
+		{"kitte", "きって"},
+		{"zasshi", "ざっし"},
+		{"matcha", "まっちゃ"},
+		// ん
+		{"shinbun", "しんぶん"},
+		{"kon'ya", "こんや"},
+		{"konnichiha", "こんにちは"},
+		{"kinyoubi", "きにょうび"},
+		// Long vowels
+		{"ra-men", "らーめん"},
+		{"tōkyō", "とうきょう"},
+		{"okâsan", "おかあさん"},
+		// Kana and unfinished syllables
+		{"カタカナ", "かたかな"},
+		{"さくr", "さくr"},
+		{"Sakura", "さくら"},
+	}
+
+	for _, tt := range tests {
+		if got := RomajiToKana(tt.input); got != tt.want {
+			t.Errorf("RomajiToKana(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSplitMorae(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"さくら", []string{"さ", "く", "ら"}},
+		// Yōon and small vowels join the kana before them
+		{"きょうと", []string{"きょ", "う", "と"}},
+		{"しゃしん", []string{"しゃ", "し", "ん"}},
+		{"ふぁいる", []string{"ふぁ", "い", "る"}},
+		// Sokuon and the long-vowel mark are morae of their own
+		{"きって", []string{"き", "っ", "て"}},
+		{"らーめん", []string{"ら", "ー", "め", "ん"}},
+	}
+
+	for _, tt := range tests {
+		got := SplitMorae(tt.word)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("SplitMorae(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+		if count := MoraCount(tt.word); count != len(tt.want) {
+			t.Errorf("MoraCount(%q) = %d, want %d", tt.word, count, len(tt.want))
+		}
+	}
+}
+
+func TestNewKanaWordList(t *testing.T) {
+	wl, err := NewKanaWordList(strings.NewReader("さくら\nラーメン\nりんご\nkana\nきって\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"さくら", true},
+		// Katakana is folded, and words ending in ん are dropped
+		{"らーめん", false},
+		{"りんご", true},
+		{"kana", false},
+		{"きって", true},
+	}
+	for _, tt := range tests {
+		if got := wl.IsValidWord(tt.word); got != tt.want {
+			t.Errorf("IsValidWord(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package oshirigame
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Language describes a dictionary language and the letters atama and oshiri
+// are drawn from.
+type Language struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	Script   string   `json:"script"`
+	Alphabet []string `json:"alphabet"`
+}
+
+const (
+	ScriptLatin = "latin"
+	ScriptKana  = "kana"
+)
+
+const (
+	English = "en"
+	Swedish = "sv"
+	German  = "de"
+)
+
+const latinLetters = "abcdefghijklmnopqrstuvwxyz"
+
+var (
+	LanguageEnglish = Language{
+		Code:     English,
+		Name:     "English",
+		Script:   ScriptLatin,
+		Alphabet: letters(latinLetters),
+	}
+	LanguageSwedish = Language{
+		Code:     Swedish,
+		Name:     "Svenska",
+		Script:   ScriptLatin,
+		Alphabet: letters(latinLetters + "åäö"),
+	}
+	LanguageGerman = Language{
+		Code:     German,
+		Name:     "Deutsch",
+		Script:   ScriptLatin,
+		Alphabet: letters(latinLetters + "äöüß"),
+	}
+)
+
+func letters(s string) []string {
+	return strings.Split(s, "")
+}
+
+// Length counts the units of word that score points: morae for kana and
+// letters for everything else.
+func (l Language) Length(word string) int {
+	if l.Script == ScriptKana {
+		return MoraCount(word)
+	}
+	return utf8.RuneCountInString(word)
+}
+
+// Split breaks word into the units atama and oshiri are made of.
+func (l Language) Split(word string) []string {
+	if l.Script == ScriptKana {
+		return SplitMorae(word)
+	}
+	return strings.Split(word, "")
+}
@@ -0,0 +1,138 @@
+package oshirigame
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Letter pools a room can draw its atama and oshiri from.
+const (
+	// PoolUniform gives every letter the same odds.
+	PoolUniform = "uniform"
+	// PoolFrequency weights letters by how many words start with them, for
+	// an atama, or end with them, for an oshiri.
+	PoolFrequency = "frequency"
+)
+
+func ValidPool(pool string) bool {
+	return pool == PoolUniform || pool == PoolFrequency
+}
+
+// yoonOdds is how often a kana mora drawn uniformly turns into one of its
+// yōon, which would otherwise outnumber the plain i-column kana.
+const yoonOdds = 0.25
+
+// LetterPool draws atama or oshiri with fixed odds.
+type LetterPool struct {
+	fragments  []string
+	cumulative []float64
+	index      map[string]bool
+}
+
+// NewLetterPool builds the pool of fragments of n units for one side of the
+// word. Fragments using an excluded letter are left out. Longer fragments
+// only come from the ones words actually start or end with.
+func NewLetterPool(dictionary Dictionary, n int, end bool, pool string, excluded []string) *LetterPool {
+	weights := uniformWeights(dictionary, n, end)
+	if pool == PoolFrequency {
+		counts := dictionary.PrefixCounts(max(n, 1))
+		if end {
+			counts = dictionary.SuffixCounts(max(n, 1))
+		}
+		for fragment := range weights {
+			weights[fragment] = float64(counts[fragment])
+		}
+	}
+
+	fragments := make([]string, 0, len(weights))
+	for fragment, weight := range weights {
+		if weight > 0 && !usesLetter(dictionary.Language(), fragment, excluded) {
+			fragments = append(fragments, fragment)
+		}
+	}
+	// Sorted so that a seeded generator always draws the same fragments.
+	sort.Strings(fragments)
+
+	p := &LetterPool{
+		fragments:  fragments,
+		cumulative: make([]float64, len(fragments)),
+		index:      make(map[string]bool, len(fragments)),
+	}
+	total := 0.0
+	for i, fragment := range fragments {
+		total += weights[fragment]
+		p.cumulative[i] = total
+		p.index[fragment] = true
+	}
+	return p
+}
+
+func uniformWeights(dictionary Dictionary, n int, end bool) map[string]float64 {
+	weights := make(map[string]float64)
+	language := dictionary.Language()
+	switch {
+	case n > 1 && end:
+		for _, fragment := range dictionary.Suffixes(n) {
+			weights[fragment] = 1
+		}
+	case n > 1:
+		for _, fragment := range dictionary.Prefixes(n) {
+			weights[fragment] = 1
+		}
+	case language.Script == ScriptKana:
+		for _, mora := range Morae {
+			yoon, ok := Yoon[mora]
+			if !ok {
+				weights[mora] = 1
+				continue
+			}
+			weights[mora] = 1 - yoonOdds
+			for _, y := range yoon {
+				weights[y] = yoonOdds / float64(len(yoon))
+			}
+		}
+	default:
+		for _, letter := range language.Alphabet {
+			weights[letter] = 1
+		}
+	}
+	return weights
+}
+
+// usesLetter reports whether fragment contains any of letters, either as a
+// whole unit or, for kana yōon, as part of one.
+func usesLetter(language Language, fragment string, letters []string) bool {
+	for _, letter := range letters {
+		if letter == "" {
+			continue
+		}
+		for _, unit := range language.Split(fragment) {
+			if strings.Contains(unit, letter) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *LetterPool) Len() int {
+	return len(p.fragments)
+}
+
+func (p *LetterPool) Contains(fragment string) bool {
+	return p.index[fragment]
+}
+
+// Pick draws a fragment, or returns "" from an empty pool.
+func (p *LetterPool) Pick(rng *rand.Rand) string {
+	if len(p.fragments) == 0 {
+		return ""
+	}
+	r := rng.Float64() * p.cumulative[len(p.cumulative)-1]
+	i := sort.SearchFloat64s(p.cumulative, r)
+	if i == len(p.fragments) {
+		i--
+	}
+	return p.fragments[i]
+}
@@ -0,0 +1,269 @@
+package oshirigame
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Every file in wordlists is embedded, so a language becomes available by
+// dropping its word list, or a Hunspell <code>.dic and <code>.aff pair, in
+// there before building.
+//
+//go:embed wordlists
+var wordlists embed.FS
+
+// registration is a registry entry. The word list is loaded at most once and
+// then shared read-only by every game that asks for it. Word frequency data
+// is picked up from wordlists/freq_<code>.txt when that file exists, or from
+// the directory given to SetFrequencyDir.
+type registration struct {
+	language   Language
+	file       string
+	required   bool
+	read       func(r io.Reader) (Dictionary, error)
+	once       sync.Once
+	dictionary Dictionary
+	err        error
+}
+
+var registry = map[string]*registration{
+	English:  {language: LanguageEnglish, file: "wordlists/words_alpha.txt", required: true},
+	Swedish:  {language: LanguageSwedish, file: "wordlists/words_sv.txt"},
+	Japanese: {language: LanguageJapanese, file: "wordlists/words_ja.txt", read: readKanaWordList},
+}
+
+// hunspellLanguages are languages that ship without a word list but keep
+// their name and alphabet when their Hunspell files are dropped in.
+var hunspellLanguages = map[string]Language{
+	German: LanguageGerman,
+}
+
+// Hunspell dictionaries for languages that are not registered above are
+// picked up as well. Unless the language is known, its alphabet is made of
+// the letters its words use.
+func init() {
+	names, _ := fs.Glob(wordlists, "wordlists/*.dic")
+	for _, name := range names {
+		code := strings.TrimSuffix(path.Base(name), ".dic")
+		if _, ok := registry[code]; ok {
+			continue
+		}
+		language, ok := hunspellLanguages[code]
+		if !ok {
+			language = Language{Code: code, Name: code, Script: ScriptLatin}
+		}
+		registry[code] = &registration{language: language}
+	}
+}
+
+func readKanaWordList(r io.Reader) (Dictionary, error) {
+	return NewKanaWordList(r)
+}
+
+func exists(name string) bool {
+	if name == "" {
+		return false
+	}
+	_, err := fs.Stat(wordlists, name)
+	return err == nil
+}
+
+func (r *registration) hunspellFiles() (string, string) {
+	base := "wordlists/" + r.language.Code
+	return base + ".dic", base + ".aff"
+}
+
+// hasHunspell reports whether the language can be built from a Hunspell pair.
+// Kana dictionaries need their own reader and only load plain lists.
+func (r *registration) hasHunspell() bool {
+	dic, aff := r.hunspellFiles()
+	return r.read == nil && exists(dic) && exists(aff)
+}
+
+func (r *registration) available() bool {
+	return r.required || exists(r.file) || r.hasHunspell()
+}
+
+func (r *registration) load() (Dictionary, error) {
+	r.once.Do(func() {
+		if !r.required && !exists(r.file) && r.hasHunspell() {
+			r.dictionary, r.err = r.loadHunspell()
+		} else {
+			r.dictionary, r.err = r.loadFile()
+		}
+		if r.err != nil {
+			return
+		}
+
+		if r.err = r.loadFrequencies(); r.err != nil {
+			return
+		}
+		if r.err = r.loadLemmas(); r.err != nil {
+			return
+		}
+
+		// Rate the default single letter pairs up front so the first room
+		// does not wait for them.
+		r.dictionary.Pairs(1, 1)
+	})
+	return r.dictionary, r.err
+}
+
+func (r *registration) loadFile() (Dictionary, error) {
+	file, err := wordlists.Open(r.file)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var dictionary Dictionary
+	if r.read != nil {
+		dictionary, err = r.read(file)
+	} else {
+		dictionary, err = NewWordList(r.language, file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", r.file, err)
+	}
+	return dictionary, nil
+}
+
+func (r *registration) loadHunspell() (Dictionary, error) {
+	dicName, affName := r.hunspellFiles()
+	dic, err := wordlists.Open(dicName)
+	if err != nil {
+		return nil, err
+	}
+	defer dic.Close()
+
+	aff, err := wordlists.Open(affName)
+	if err != nil {
+		return nil, err
+	}
+	defer aff.Close()
+
+	wl, err := NewHunspellWordList(r.language, dic, aff)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", dicName, err)
+	}
+	return wl, nil
+}
+
+// wordListBacked is implemented by dictionaries built on a WordList, giving
+// the registry access to it while the dictionary is still being loaded.
+type wordListBacked interface {
+	wordList() *WordList
+}
+
+// loadExtra feeds the optional file <prefix>_<code>.txt in dir of fsys to
+// load. It reports false when there is no such file.
+func (r *registration) loadExtra(fsys fs.FS, dir string, prefix string, load func(wl *WordList, r io.Reader) error) (bool, error) {
+	backed, ok := r.dictionary.(wordListBacked)
+	if !ok {
+		return false, nil
+	}
+
+	name := path.Join(dir, prefix+"_"+r.language.Code+".txt")
+	file, err := fsys.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	if err := load(backed.wordList(), file); err != nil {
+		return true, fmt.Errorf("loading %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// frequencyDir holds freq_<code>.txt files that replace the built-in ones.
+var frequencyDir string
+
+// SetFrequencyDir makes dictionaries read their word frequencies from
+// dir/freq_<code>.txt, falling back to the built-in data for languages the
+// directory has no file for. It must be called before any dictionary is
+// loaded.
+func SetFrequencyDir(dir string) {
+	frequencyDir = dir
+}
+
+func (r *registration) loadFrequencies() error {
+	if frequencyDir != "" {
+		found, err := r.loadExtra(os.DirFS(frequencyDir), ".", "freq", (*WordList).loadFrequencies)
+		if found || err != nil {
+			return err
+		}
+	}
+	_, err := r.loadExtra(wordlists, "wordlists", "freq", (*WordList).loadFrequencies)
+	return err
+}
+
+// loadLemmas prefers a shipped lemma mapping. Without one, English plain
+// word lists fall back to derived lemmas; Hunspell lists already have theirs.
+func (r *registration) loadLemmas() error {
+	found, err := r.loadExtra(wordlists, "wordlists", "lemmas", (*WordList).loadLemmas)
+	if found || err != nil {
+		return err
+	}
+
+	backed, ok := r.dictionary.(wordListBacked)
+	if ok && r.language.Code == English && backed.wordList().lemmas == nil {
+		backed.wordList().deriveEnglishLemmas()
+	}
+	return nil
+}
+
+// GetDictionary returns the shared dictionary for a language code, loading it
+// on first use.
+func GetDictionary(code string) (Dictionary, error) {
+	r, ok := registry[code]
+	if !ok || !r.available() {
+		return nil, fmt.Errorf("unsupported language %q", code)
+	}
+	return r.load()
+}
+
+// Languages lists the languages whose word lists are available, ordered by
+// code. The alphabet is taken from the loaded dictionary, since languages
+// found through their Hunspell files only get one once their words are read.
+func Languages() []Language {
+	languages := make([]Language, 0, len(registry))
+	for _, r := range registry {
+		if !r.available() {
+			continue
+		}
+		if dictionary, err := r.load(); err == nil {
+			languages = append(languages, dictionary.Language())
+		}
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		return languages[i].Code < languages[j].Code
+	})
+	return languages
+}
+
+// LoadDictionaries loads every available dictionary up front so that the
+// server can refuse to start instead of running with a broken word list.
+func LoadDictionaries() error {
+	var errs []error
+	for _, r := range registry {
+		if !r.available() {
+			continue
+		}
+		if _, err := r.load(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,308 @@
+package oshirigame
+
+import (
+	"sort"
+	"strings"
+)
+
+// Names of the scoring strategies a room can choose between.
+const (
+	ScoringClassic  = "classic"
+	ScoringRarity   = "rarity"
+	ScoringScrabble = "scrabble"
+	ScoringSpeed    = "speed"
+	ScoringStreak   = "streak"
+	ScoringPenalty  = "penalty"
+)
+
+// ScoreItem is one line of a score breakdown.
+type ScoreItem struct {
+	Label  string `json:"label"`
+	Points int    `json:"points"`
+}
+
+// ScoreBreakdown explains how a word's score was reached.
+type ScoreBreakdown struct {
+	Items []ScoreItem `json:"items"`
+	Total int         `json:"total"`
+}
+
+func (s *ScoreBreakdown) Add(label string, points int) {
+	if points == 0 {
+		return
+	}
+	s.Items = append(s.Items, ScoreItem{Label: label, Points: points})
+	s.Total += points
+}
+
+// RoundResult records how a player did in one round.
+type RoundResult struct {
+	Word     string `json:"word"`
+	Accepted bool   `json:"accepted"`
+	Score    int    `json:"score"`
+}
+
+// RoundContext is everything a scoring strategy gets to look at.
+type RoundContext struct {
+	Dictionary Dictionary
+	Atama      string
+	Input      string
+	Oshiri     string
+	// Accepted is false when the word is not in the dictionary or was
+	// rejected by the room's filters.
+	Accepted bool
+	// TimeLeft is how many seconds were left on the clock when the player
+	// last changed their input.
+	TimeLeft  int
+	RoundTime int
+	// History holds the player's earlier rounds, oldest first.
+	History []RoundResult
+	// Lemma is set when the room only scores base forms and the word is an
+	// inflection of it.
+	Lemma string
+}
+
+func (r RoundContext) Word() string {
+	return r.Atama + r.Input + r.Oshiri
+}
+
+// ScoredInput is the part of the input that earns points. Without a lemma
+// that is the whole input; with one it is the input up to where it stops
+// following the lemma, so "walk|in|g" only scores "alk" of "alkin".
+func (r RoundContext) ScoredInput() string {
+	if r.Lemma == "" {
+		return r.Input
+	}
+	language := r.Dictionary.Language()
+	input := language.Split(r.Input)
+	lemma := language.Split(strings.TrimPrefix(r.Lemma, r.Atama))
+
+	n := 0
+	for n < len(input) && n < len(lemma) && input[n] == lemma[n] {
+		n++
+	}
+	return strings.Join(input[:n], "")
+}
+
+// ScoringStrategy turns a round into points.
+type ScoringStrategy interface {
+	Name() string
+	// Formula describes the strategy to players.
+	Formula() string
+	Score(round RoundContext) ScoreBreakdown
+}
+
+var scoringStrategies = map[string]ScoringStrategy{
+	ScoringClassic:  classicScoring{},
+	ScoringRarity:   rarityScoring{},
+	ScoringScrabble: scrabbleScoring{},
+	ScoringSpeed:    speedScoring{},
+	ScoringStreak:   streakScoring{},
+	ScoringPenalty:  penaltyScoring{},
+}
+
+func GetScoringStrategy(name string) (ScoringStrategy, bool) {
+	strategy, ok := scoringStrategies[name]
+	return strategy, ok
+}
+
+// ScoringStrategies lists every strategy, ordered by name.
+func ScoringStrategies() []ScoringStrategy {
+	strategies := make([]ScoringStrategy, 0, len(scoringStrategies))
+	for _, strategy := range scoringStrategies {
+		strategies = append(strategies, strategy)
+	}
+	sort.Slice(strategies, func(i, j int) bool {
+		return strategies[i].Name() < strategies[j].Name()
+	})
+	return strategies
+}
+
+// lengthScore adds the classic point per typed letter for accepted words.
+func lengthScore(score *ScoreBreakdown, round RoundContext) {
+	if round.Accepted {
+		if round.Lemma != "" {
+			score.Add("Length", round.Dictionary.Language().Length(round.ScoredInput()))
+			return
+		}
+		points := round.Dictionary.GetScore(round.Atama, round.Input, round.Oshiri)
+		if points == 0 {
+			// Words voted in by a challenge aren't in the dictionary
+			points = round.Dictionary.Language().Length(round.Input)
+		}
+		score.Add("Length", points)
+	}
+}
+
+type classicScoring struct{}
+
+func (classicScoring) Name() string {
+	return ScoringClassic
+}
+
+func (classicScoring) Formula() string {
+	return "1 point per letter you type"
+}
+
+func (classicScoring) Score(round RoundContext) ScoreBreakdown {
+	var score ScoreBreakdown
+	lengthScore(&score, round)
+	return score
+}
+
+// Thresholds for the rarity bonuses. Word frequencies are per million words,
+// letter frequencies a share of all letters in the dictionary.
+const (
+	rareWordFrequency       = 1.0
+	uncommonWordFrequency   = 10.0
+	rareLetterFrequency     = 0.005
+	uncommonLetterFrequency = 0.015
+)
+
+type rarityScoring struct{}
+
+func (rarityScoring) Name() string {
+	return ScoringRarity
+}
+
+func (rarityScoring) Formula() string {
+	return "1 point per letter you type, plus bonuses for rare words and rare letters"
+}
+
+func (rarityScoring) Score(round RoundContext) ScoreBreakdown {
+	var score ScoreBreakdown
+	if !round.Accepted {
+		return score
+	}
+	lengthScore(&score, round)
+
+	if frequency, ok := round.Dictionary.WordFrequency(round.Word()); ok {
+		switch {
+		case frequency < rareWordFrequency:
+			score.Add("Rare word", 3)
+		case frequency < uncommonWordFrequency:
+			score.Add("Uncommon word", 1)
+		}
+	}
+
+	// Only the letters the player chose count towards the letter bonus.
+	letterBonus := 0
+	for _, letter := range round.Dictionary.Language().Split(round.ScoredInput()) {
+		switch frequency := round.Dictionary.LetterFrequency(letter); {
+		case frequency < rareLetterFrequency:
+			letterBonus += 2
+		case frequency < uncommonLetterFrequency:
+			letterBonus++
+		}
+	}
+	score.Add("Rare letters", letterBonus)
+
+	return score
+}
+
+var scrabbleValues = map[string]int{
+	"a": 1, "b": 3, "c": 3, "d": 2, "e": 1, "f": 4, "g": 2, "h": 4, "i": 1,
+	"j": 8, "k": 5, "l": 1, "m": 3, "n": 1, "o": 1, "p": 3, "q": 10, "r": 1,
+	"s": 1, "t": 1, "u": 1, "v": 4, "w": 4, "x": 8, "y": 4, "z": 10,
+	"å": 4, "ä": 3, "ö": 4, "ü": 6, "ß": 8,
+}
+
+type scrabbleScoring struct{}
+
+func (scrabbleScoring) Name() string {
+	return ScoringScrabble
+}
+
+func (scrabbleScoring) Formula() string {
+	return "Scrabble tile values of the letters you type"
+}
+
+func (scrabbleScoring) Score(round RoundContext) ScoreBreakdown {
+	var score ScoreBreakdown
+	if !round.Accepted {
+		return score
+	}
+	points := 0
+	for _, letter := range round.Dictionary.Language().Split(round.ScoredInput()) {
+		if value, ok := scrabbleValues[letter]; ok {
+			points += value
+		} else {
+			points++
+		}
+	}
+	score.Add("Letter values", points)
+	return score
+}
+
+type speedScoring struct{}
+
+func (speedScoring) Name() string {
+	return ScoringSpeed
+}
+
+func (speedScoring) Formula() string {
+	return "1 point per letter you type, doubled if you answer instantly and less the longer you take"
+}
+
+func (speedScoring) Score(round RoundContext) ScoreBreakdown {
+	var score ScoreBreakdown
+	lengthScore(&score, round)
+	if score.Total > 0 && round.RoundTime > 0 {
+		score.Add("Speed bonus", score.Total*round.TimeLeft/round.RoundTime)
+	}
+	return score
+}
+
+// maxStreak caps how many earlier accepted words raise the multiplier.
+const maxStreak = 4
+
+type streakScoring struct{}
+
+func (streakScoring) Name() string {
+	return ScoringStreak
+}
+
+func (streakScoring) Formula() string {
+	return "1 point per letter you type, plus half again for every accepted word in a row before it, up to triple"
+}
+
+func (streakScoring) Score(round RoundContext) ScoreBreakdown {
+	var score ScoreBreakdown
+	lengthScore(&score, round)
+	if score.Total == 0 {
+		return score
+	}
+
+	streak := 0
+	for i := len(round.History) - 1; i >= 0 && round.History[i].Accepted; i-- {
+		streak++
+	}
+	if streak > maxStreak {
+		streak = maxStreak
+	}
+	score.Add("Streak bonus", score.Total*streak/2)
+	return score
+}
+
+// invalidPenalty is what a rejected word costs under penalty scoring.
+const invalidPenalty = 3
+
+type penaltyScoring struct{}
+
+func (penaltyScoring) Name() string {
+	return ScoringPenalty
+}
+
+func (penaltyScoring) Formula() string {
+	return "1 point per letter you type, minus 3 points for a word that is not accepted"
+}
+
+func (penaltyScoring) Score(round RoundContext) ScoreBreakdown {
+	var score ScoreBreakdown
+	if !round.Accepted {
+		score.Add("Invalid word", -invalidPenalty)
+		return score
+	}
+	lengthScore(&score, round)
+	return score
+}
@@ -0,0 +1,83 @@
+package oshirigame
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// The supplementary list holds words players voted into the game that the
+// word lists are missing, shared by every room on the server.
+var (
+	supplementaryMu   sync.RWMutex
+	supplementary     = make(map[string]map[string]bool)
+	supplementaryPath string
+)
+
+// LoadSupplementary reads accepted words from path, one "code word" pair per
+// line, and appends words accepted from now on to it. A missing file is
+// created on the first accepted word.
+func LoadSupplementary(path string) error {
+	supplementaryMu.Lock()
+	defer supplementaryMu.Unlock()
+	supplementaryPath = path
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		addSupplementary(fields[0], fields[1])
+	}
+	return scanner.Err()
+}
+
+func addSupplementary(code string, word string) bool {
+	if supplementary[code] == nil {
+		supplementary[code] = make(map[string]bool)
+	}
+	if supplementary[code][word] {
+		return false
+	}
+	supplementary[code][word] = true
+	return true
+}
+
+// IsSupplementary reports whether word was voted into the language's words.
+func IsSupplementary(code string, word string) bool {
+	supplementaryMu.RLock()
+	defer supplementaryMu.RUnlock()
+	return supplementary[code][word]
+}
+
+// AddSupplementary accepts word for every room playing the language, saving
+// it when a supplementary file was loaded.
+func AddSupplementary(code string, word string) error {
+	supplementaryMu.Lock()
+	defer supplementaryMu.Unlock()
+	if !addSupplementary(code, word) || supplementaryPath == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(supplementaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(file, code, word); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -2,84 +2,536 @@ package oshirigame
 
 import (
 	"bufio"
-	"embed"
-	"fmt"
+	"errors"
+	"io"
+	"sort"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
-//go:embed wordlists/words_alpha.txt
-var englishWords embed.FS
+var ErrEmptyWordList = errors.New("word list is empty")
 
+// Dictionary is the read-only view of a word list that games play against.
+type Dictionary interface {
+	Language() Language
+	Normalize(input string) string
+	IsValidWord(word string) bool
+	GetScore(atama string, input string, oshiri string) int
+	WordCount(atama string, oshiri string) int
+	TopWords(atama string, oshiri string, exclude func(word string) bool) []string
+	MaxWordCount(atamaLength int, oshiriLength int) int
+	Words(atama string, oshiri string, minLength int, maxLength int) []string
+	Match(pattern string) []string
+	Suggest(atama string, oshiri string, word string, exclude func(word string) bool) []string
+	Lemma(word string) (string, bool)
+	Pairs(atamaLength int, oshiriLength int) []PairRating
+	WordFrequency(word string) (float64, bool)
+	LetterFrequency(letter string) float64
+	Prefixes(n int) []string
+	Suffixes(n int) []string
+	PrefixCounts(n int) map[string]int
+	SuffixCounts(n int) map[string]int
+}
+
+// WordList is an indexed dictionary. It is never modified after NewWordList
+// returns, so a single instance can be shared by every game.
 type WordList struct {
-	Words map[string]bool
+	language Language
+	words    map[string]bool
+	pairs    map[pairKey][]string
+
+	wordCounts   map[string]int
+	wordTotal    int
+	letterCounts map[string]int
+	letterTotal  int
+	lemmas       map[string]string
+
+	// Fragments longer than one unit are only needed by rooms that ask for
+	// them, so they are collected on first use.
+	fragmentsMu sync.Mutex
+	prefixes    map[int]*fragmentIndex
+	suffixes    map[int]*fragmentIndex
+	maxCounts   map[[2]int]int
+	ratings     map[[2]int][]PairRating
+
+	// Suggestion trees are built per bucket the first time a pair needs one.
+	treesMu sync.Mutex
+	trees   map[pairKey]*bkTree
+}
+
+// pairKey identifies the bucket of words that start with atama and end with oshiri.
+type pairKey struct {
+	atama  rune
+	oshiri rune
+}
+
+// NewWordList reads one word per line from r and indexes the result. Words
+// are lowercased, and words using letters outside the language's alphabet
+// are skipped since they could never be typed in a round.
+func NewWordList(language Language, r io.Reader) (*WordList, error) {
+	alphabet := alphabetSet(language.Alphabet)
+
+	words := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || !inAlphabet(word, alphabet) {
+			continue
+		}
+		words[word] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newIndexedWordList(language, words)
 }
 
-func NewWordList() *WordList {
+func newIndexedWordList(language Language, words map[string]bool) (*WordList, error) {
+	if len(words) == 0 {
+		return nil, ErrEmptyWordList
+	}
 	wl := &WordList{
-		Words: make(map[string]bool),
+		language:  language,
+		words:     words,
+		prefixes:  make(map[int]*fragmentIndex),
+		suffixes:  make(map[int]*fragmentIndex),
+		maxCounts: make(map[[2]int]int),
+		ratings:   make(map[[2]int][]PairRating),
+		trees:     make(map[pairKey]*bkTree),
 	}
-	FillWordList(wl)
-	return wl
+	wl.buildIndex()
+	wl.countLetters()
+	return wl, nil
+}
+
+func alphabetSet(letters []string) map[rune]bool {
+	alphabet := make(map[rune]bool)
+	for _, letter := range letters {
+		for _, r := range letter {
+			alphabet[r] = true
+		}
+	}
+	return alphabet
 }
 
-func FillWordList(wl *WordList) {
-	file, err := englishWords.Open("wordlists/words_alpha.txt")
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
+// alphabetOf lists, in order, the letters used by words.
+func alphabetOf(words map[string]bool) []string {
+	letters := make(map[string]bool)
+	for word := range words {
+		for _, r := range word {
+			letters[string(r)] = true
+		}
 	}
-	defer file.Close()
 
-	// Create a scanner to read the file line by line
-	scanner := bufio.NewScanner(file)
+	alphabet := make([]string, 0, len(letters))
+	for letter := range letters {
+		alphabet = append(alphabet, letter)
+	}
+	sort.Strings(alphabet)
+	return alphabet
+}
 
-	// Iterate through each line of the file
-	for scanner.Scan() {
-		// Get the current line
-		line := scanner.Text()
-		// Process the line (e.g., print it)
-		wl.Words[line] = true
+func inAlphabet(word string, alphabet map[rune]bool) bool {
+	for _, r := range word {
+		if !alphabet[r] {
+			return false
+		}
+	}
+	return true
+}
+
+// buildIndex groups every word by its first and last letter so that pair
+// lookups only touch the words that can match. Each bucket is sorted by
+// score, longest words first, with ties broken alphabetically.
+func (wl *WordList) buildIndex() {
+	wl.pairs = make(map[pairKey][]string)
+	for word := range wl.words {
+		key, ok := keyOf(word)
+		if !ok {
+			continue
+		}
+		wl.pairs[key] = append(wl.pairs[key], word)
 	}
 
-	// Check for any errors during scanning
-	if err := scanner.Err(); err != nil {
-		fmt.Println("Error:", err)
+	for _, words := range wl.pairs {
+		wl.sortByScore(words)
 	}
 }
 
-func (wl *WordList) GetScore(word string) int {
-	if wl.Words[word] {
-		return len(word) - 2
+func (wl *WordList) sortByScore(words []string) {
+	sort.Slice(words, func(i, j int) bool {
+		li, lj := wl.language.Length(words[i]), wl.language.Length(words[j])
+		if li != lj {
+			return li > lj
+		}
+		return words[i] < words[j]
+	})
+}
+
+func keyOf(word string) (pairKey, bool) {
+	if word == "" {
+		return pairKey{}, false
+	}
+	first, _ := utf8.DecodeRuneInString(word)
+	last, _ := utf8.DecodeLastRuneInString(word)
+	return pairKey{atama: first, oshiri: last}, true
+}
+
+// keys returns the index buckets that can hold words starting with atama
+// and ending with oshiri, either of which may be empty.
+func (wl *WordList) keys(atama string, oshiri string) []pairKey {
+	first, _ := utf8.DecodeRuneInString(atama)
+	last, _ := utf8.DecodeLastRuneInString(oshiri)
+	if atama != "" && oshiri != "" {
+		return []pairKey{{atama: first, oshiri: last}}
+	}
+
+	var keys []pairKey
+	for key := range wl.pairs {
+		if (atama == "" || key.atama == first) && (oshiri == "" || key.oshiri == last) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// buckets returns the indexed words that can start with atama and end with
+// oshiri. When both are at most one letter long every word in the buckets
+// matches; otherwise the words still have to be checked with matches.
+func (wl *WordList) buckets(atama string, oshiri string) ([][]string, bool) {
+	exact := utf8.RuneCountInString(atama) <= 1 && utf8.RuneCountInString(oshiri) <= 1
+	var buckets [][]string
+	for _, key := range wl.keys(atama, oshiri) {
+		buckets = append(buckets, wl.pairs[key])
+	}
+	return buckets, exact
+}
+
+// matches reports whether word can be played as atama, some input and oshiri.
+func matches(word string, atama string, oshiri string) bool {
+	return len(word) >= len(atama)+len(oshiri) &&
+		strings.HasPrefix(word, atama) &&
+		strings.HasSuffix(word, oshiri)
+}
+
+func (wl *WordList) wordList() *WordList {
+	return wl
+}
+
+func (wl *WordList) Language() Language {
+	return wl.language
+}
+
+func (wl *WordList) Normalize(input string) string {
+	return strings.ToLower(input)
+}
+
+func (wl *WordList) Len() int {
+	return len(wl.words)
+}
+
+// GetScore awards a point for every unit of the word that was not given to
+// the player as atama or oshiri.
+func (wl *WordList) GetScore(atama string, input string, oshiri string) int {
+	word := atama + input + oshiri
+	if wl.words[word] {
+		return wl.language.Length(word) - wl.language.Length(atama) - wl.language.Length(oshiri)
 	}
 	return 0
 }
 
-func (wl *WordList) TopWords(atama string, oshiri string) []string {
+// TopWords returns the three highest scoring words for a pair, skipping any
+// word exclude reports. exclude may be nil.
+func (wl *WordList) TopWords(atama string, oshiri string, exclude func(word string) bool) []string {
 	words := make([]string, 3)
-	for word := range wl.Words {
-		if strings.HasPrefix(word, atama) && strings.HasSuffix(word, oshiri) {
-			for i := 0; i < 3; i++ {
-				if len(word) > len(words[i]) {
-					copy(words[i+1:], words[i:])
-					words[i] = word
-					break
-				}
-			}
+	candidates, exact := wl.candidates(atama, oshiri)
+	i := 0
+	for _, word := range candidates {
+		if i == len(words) {
+			break
+		}
+		if (exact || matches(word, atama, oshiri)) && (exclude == nil || !exclude(word)) {
+			words[i] = word
+			i++
 		}
 	}
 	return words
 }
 
+// candidates returns the words to check for a pair in score order, using the
+// index directly when the pair maps to a single bucket.
+func (wl *WordList) candidates(atama string, oshiri string) ([]string, bool) {
+	buckets, exact := wl.buckets(atama, oshiri)
+	if len(buckets) == 1 {
+		return buckets[0], exact
+	}
+	return wl.Words(atama, oshiri, 0, 0), true
+}
+
 func (wl *WordList) WordCount(atama string, oshiri string) int {
+	buckets, exact := wl.buckets(atama, oshiri)
 	count := 0
-	for word := range wl.Words {
-		if strings.HasPrefix(word, atama) && strings.HasSuffix(word, oshiri) {
-			count++
+	for _, bucket := range buckets {
+		if exact {
+			count += len(bucket)
+			continue
+		}
+		for _, word := range bucket {
+			if matches(word, atama, oshiri) {
+				count++
+			}
 		}
 	}
 	return count
 }
 
+// Words returns, in score order, every word that can be played with atama
+// and oshiri and whose length in units lies between minLength and maxLength.
+// Either fragment may be empty and either bound zero to leave it open.
+func (wl *WordList) Words(atama string, oshiri string, minLength int, maxLength int) []string {
+	buckets, exact := wl.buckets(atama, oshiri)
+	words := make([]string, 0)
+	for _, bucket := range buckets {
+		for _, word := range bucket {
+			if !exact && !matches(word, atama, oshiri) {
+				continue
+			}
+			length := wl.language.Length(word)
+			if (minLength > 0 && length < minLength) || (maxLength > 0 && length > maxLength) {
+				continue
+			}
+			words = append(words, word)
+		}
+	}
+	if len(buckets) > 1 {
+		wl.sortByScore(words)
+	}
+	return words
+}
+
+// Match returns, in score order, the words matching a glob pattern in which
+// ? stands for exactly one unit and * for any number of them.
+func (wl *WordList) Match(pattern string) []string {
+	units := wl.language.Split(pattern)
+	if len(units) == 0 {
+		return []string{}
+	}
+
+	// A literal first or last unit narrows the search to a few buckets.
+	var atama, oshiri string
+	if !IsWildcard(units[0]) {
+		atama = units[0]
+	}
+	if !IsWildcard(units[len(units)-1]) {
+		oshiri = units[len(units)-1]
+	}
+
+	words := make([]string, 0)
+	for _, word := range wl.Words(atama, oshiri, 0, 0) {
+		if glob(units, wl.language.Split(word)) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// IsWildcard reports whether a unit of a Match pattern is ? or *.
+func IsWildcard(unit string) bool {
+	return unit == "?" || unit == "*"
+}
+
+// glob matches units against pattern, backtracking to the last * on a
+// mismatch.
+func glob(pattern []string, units []string) bool {
+	p, u := 0, 0
+	star, mark := -1, 0
+	for u < len(units) {
+		switch {
+		case p < len(pattern) && (pattern[p] == "?" || pattern[p] == units[u]):
+			p++
+			u++
+		case p < len(pattern) && pattern[p] == "*":
+			star, mark = p, u
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			u = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == "*" {
+		p++
+	}
+	return p == len(pattern)
+}
+
 func (wl *WordList) IsValidWord(word string) bool {
-	return wl.Words[word]
+	return wl.words[word]
+}
+
+// fragmentIndex holds the atama or oshiri of one length that words have,
+// in order, and how many words have each.
+type fragmentIndex struct {
+	sorted []string
+	counts map[string]int
+}
+
+// Prefixes lists, in order, every atama of n units that starts a word.
+func (wl *WordList) Prefixes(n int) []string {
+	return wl.prefixIndex(n).sorted
+}
+
+// Suffixes lists, in order, every oshiri of n units that ends a word.
+func (wl *WordList) Suffixes(n int) []string {
+	return wl.suffixIndex(n).sorted
+}
+
+// PrefixCounts maps every atama of n units to how many words start with it.
+// The map is shared and must not be modified.
+func (wl *WordList) PrefixCounts(n int) map[string]int {
+	return wl.prefixIndex(n).counts
+}
+
+// SuffixCounts is PrefixCounts for the end of words.
+func (wl *WordList) SuffixCounts(n int) map[string]int {
+	return wl.suffixIndex(n).counts
+}
+
+func (wl *WordList) prefixIndex(n int) *fragmentIndex {
+	return wl.fragments(wl.prefixes, n, func(units []string) []string {
+		return units[:n]
+	})
+}
+
+func (wl *WordList) suffixIndex(n int) *fragmentIndex {
+	return wl.fragments(wl.suffixes, n, func(units []string) []string {
+		return units[len(units)-n:]
+	})
+}
+
+func (wl *WordList) fragments(cache map[int]*fragmentIndex, n int, cut func(units []string) []string) *fragmentIndex {
+	wl.fragmentsMu.Lock()
+	defer wl.fragmentsMu.Unlock()
+	if fragments, ok := cache[n]; ok {
+		return fragments
+	}
+
+	counts := make(map[string]int)
+	for word := range wl.words {
+		units := wl.language.Split(word)
+		// Leave room for at least one unit on the other side.
+		if len(units) <= n {
+			continue
+		}
+		counts[strings.Join(cut(units), "")]++
+	}
+
+	sorted := make([]string, 0, len(counts))
+	for fragment := range counts {
+		sorted = append(sorted, fragment)
+	}
+	sort.Strings(sorted)
+	cache[n] = &fragmentIndex{sorted: sorted, counts: counts}
+	return cache[n]
+}
+
+// MaxWordCount is the highest WordCount any atama and oshiri of the given
+// lengths reach, which is as many word combinations as a room can demand.
+func (wl *WordList) MaxWordCount(atamaLength int, oshiriLength int) int {
+	if atamaLength <= 1 && oshiriLength <= 1 {
+		max := 0
+		for _, words := range wl.pairs {
+			if len(words) > max {
+				max = len(words)
+			}
+		}
+		return max
+	}
+
+	wl.fragmentsMu.Lock()
+	defer wl.fragmentsMu.Unlock()
+	lengths := [2]int{atamaLength, oshiriLength}
+	if max, ok := wl.maxCounts[lengths]; ok {
+		return max
+	}
+
+	counts := make(map[[2]string]int)
+	max := 0
+	for word := range wl.words {
+		units := wl.language.Split(word)
+		if len(units) < atamaLength+oshiriLength {
+			continue
+		}
+		pair := [2]string{
+			strings.Join(units[:atamaLength], ""),
+			strings.Join(units[len(units)-oshiriLength:], ""),
+		}
+		counts[pair]++
+		if counts[pair] > max {
+			max = counts[pair]
+		}
+	}
+	wl.maxCounts[lengths] = max
+	return max
+}
+
+// maxSuggestions is how many near misses Suggest returns at most.
+const maxSuggestions = 3
+
+// Suggest finds words close to a rejected word that still fit atama and
+// oshiri, nearest first. Short words only get suggestions one edit away so
+// that they are not swamped by unrelated words. exclude may be nil.
+func (wl *WordList) Suggest(atama string, oshiri string, word string, exclude func(word string) bool) []string {
+	maxDistance := 2
+	if utf8.RuneCountInString(word) <= 4 {
+		maxDistance = 1
+	}
+
+	type suggestion struct {
+		word     string
+		distance int
+	}
+	var found []suggestion
+	for _, key := range wl.keys(atama, oshiri) {
+		wl.tree(key).search(word, maxDistance, func(candidate string, distance int) {
+			if distance == 0 || !matches(candidate, atama, oshiri) || (exclude != nil && exclude(candidate)) {
+				return
+			}
+			found = append(found, suggestion{word: candidate, distance: distance})
+		})
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].distance != found[j].distance {
+			return found[i].distance < found[j].distance
+		}
+		return found[i].word < found[j].word
+	})
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, s := range found {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, s.word)
+	}
+	return suggestions
+}
+
+func (wl *WordList) tree(key pairKey) *bkTree {
+	wl.treesMu.Lock()
+	defer wl.treesMu.Unlock()
+	if tree, ok := wl.trees[key]; ok {
+		return tree
+	}
+
+	tree := &bkTree{}
+	for _, word := range wl.pairs[key] {
+		tree.add(word)
+	}
+	wl.trees[key] = tree
+	return tree
 }
@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/carl1330/oshirigame/internal/oshirigame"
+)
+
+// How the oshiri is chosen in chain mode.
+const (
+	// ChainOshiriRandom keeps drawing a random oshiri.
+	ChainOshiriRandom = "random"
+	// ChainOshiriNone drops the oshiri, so any word starting with the atama
+	// will do.
+	ChainOshiriNone = "none"
+)
+
+func validChainOshiri(chainOshiri string) bool {
+	return chainOshiri == ChainOshiriRandom || chainOshiri == ChainOshiriNone
+}
+
+func (g *game) SetChainOshiri(chainOshiri string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.ChainOshiri = chainOshiri
+}
+
+// ChainLink is the atama the chain continues from: the end of the last
+// accepted word, as long as the atama is allowed to be. It is empty outside
+// chain mode and before the first word.
+func (g *game) ChainLink() string {
+	dictionary := g.GetDictionary()
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if g.GameState.Mode != ModeChain || len(g.GameState.Chain) == 0 {
+		return ""
+	}
+
+	units := dictionary.Language().Split(g.GameState.Chain[len(g.GameState.Chain)-1])
+	n := min(max(g.GameState.AtamaLength, 1), len(units))
+	return strings.Join(units[len(units)-n:], "")
+}
+
+// AddToChain links an accepted word into the chain.
+func (g *game) AddToChain(word string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Chain = append(g.GameState.Chain, word)
+}
+
+// ClearChain forgets the chain and the used words for a new game.
+func (g *game) ClearChain() {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Chain = nil
+	g.GameState.UsedWords = make(map[string]bool)
+}
+
+// PickChainPair picks an oshiri to go with the atama the chain continues
+// from. When no eligible pair starts with the atama the oshiri is dropped
+// rather than breaking the chain. With a letter bag the oshiri's tiles are
+// taken out of the settings' bag. It reports false when no word starts with
+// the atama at all.
+func (s pairSettings) PickChainPair(rng *rand.Rand, atama string) (string, string, bool) {
+	if s.chainOshiri == ChainOshiriRandom && s.letterSource == oshirigame.LetterSourceBag {
+		if oshiri, ok := s.chainOshiriFromBag(rng, atama); ok {
+			return atama, oshiri, true
+		}
+	} else if s.chainOshiri == ChainOshiriRandom {
+		_, oshiriPool := s.LetterPools()
+		var eligible []oshirigame.PairRating
+		for _, pair := range s.ratedPairs() {
+			if pair.Atama == atama && oshiriPool.Contains(pair.Oshiri) {
+				eligible = append(eligible, pair)
+			}
+		}
+
+		if len(eligible) > 0 {
+			allowed := make(map[string]bool, len(eligible))
+			for _, pair := range eligible {
+				allowed[pair.Oshiri] = true
+			}
+			for i := 0; i < maxPairDraws; i++ {
+				if oshiri := oshiriPool.Pick(rng); allowed[oshiri] {
+					return atama, oshiri, true
+				}
+			}
+			return atama, eligible[rng.Intn(len(eligible))].Oshiri, true
+		}
+	}
+
+	return atama, "", s.dictionary.WordCount(atama, "") > 0
+}
+
+// chainOshiriFromBag draws the oshiri's tiles out of the bag, putting them
+// back if they do not end a word that starts with the atama.
+func (s pairSettings) chainOshiriFromBag(rng *rand.Rand, atama string) (string, bool) {
+	bag := s.Bag()
+	language := s.dictionary.Language()
+
+	allowed := make(map[string]bool)
+	var eligible []string
+	for _, pair := range s.ratedPairs() {
+		if pair.Atama == atama && !allowed[pair.Oshiri] && bag.CanSpell(language, pair.Oshiri) {
+			allowed[pair.Oshiri] = true
+			eligible = append(eligible, pair.Oshiri)
+		}
+	}
+	if len(eligible) == 0 {
+		return "", false
+	}
+
+	for i := 0; i < maxPairDraws; i++ {
+		if oshiri := drawFragment(rng, bag.Copy(), s.oshiriLength); allowed[oshiri] {
+			bag.Take(language, oshiri)
+			return oshiri, true
+		}
+	}
+	oshiri := eligible[rng.Intn(len(eligible))]
+	bag.Take(language, oshiri)
+	return oshiri, true
+}
@@ -0,0 +1,260 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/carl1330/oshirigame/internal/oshirigame"
+)
+
+// Where words accepted by a challenge vote are kept.
+const (
+	// ChallengeSaveOff only awards the points; the word stays rejected.
+	ChallengeSaveOff = "off"
+	// ChallengeSaveRoom accepts the word for the rest of the room's games.
+	ChallengeSaveRoom = "room"
+	// ChallengeSaveServer accepts the word in every room on the server.
+	ChallengeSaveServer = "server"
+)
+
+func validChallengeSave(save string) bool {
+	return save == ChallengeSaveOff || save == ChallengeSaveRoom || save == ChallengeSaveServer
+}
+
+// maxChallengeTime is the longest a challenge vote can run, in seconds.
+const maxChallengeTime = 30
+
+func validChallengeTime(challengeTime int) bool {
+	return challengeTime >= 0 && challengeTime <= maxChallengeTime
+}
+
+// challenge is a vote on a rejected answer.
+type challenge struct {
+	answer answer
+	votes  map[*Player]bool
+	voters int
+	voted  chan struct{} // Closed once every voter has voted
+}
+
+// SetChallenges sets how long players get to vote on an appeal, with 0
+// turning appeals off, and where accepted words are saved. A negative time or
+// empty save leaves that setting unchanged.
+func (g *game) SetChallenges(challengeTime int, save string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if challengeTime >= 0 {
+		g.GameState.ChallengeTime = challengeTime
+	}
+	if save != "" {
+		g.GameState.ChallengeSave = save
+	}
+}
+
+// Supplementary reports whether a word the dictionary lacks was voted in,
+// for this room or the whole server.
+func (g *game) Supplementary(dictionary oshirigame.Dictionary, word string) bool {
+	g.GameState.Lock()
+	accepted := g.GameState.AcceptedWords[word]
+	g.GameState.Unlock()
+	code := dictionary.Language().Code
+	return accepted || (code != oshirigame.Custom && oshirigame.IsSupplementary(code, word))
+}
+
+// clearAppeals forgets the previous round's rejected answers.
+func (g *game) clearAppeals() {
+	g.Lock()
+	defer g.Unlock()
+	g.appeals = make(map[*Player]answer)
+}
+
+func (g *game) setOver(over bool) {
+	g.Lock()
+	defer g.Unlock()
+	g.over = over
+}
+
+// addAppeal lets a player appeal a rejected answer until the next round.
+// Only words missing from the dictionary can be appealed, not empty answers
+// or words the room's rules turned away.
+func (g *game) addAppeal(a answer) {
+	if a.accepted || a.input == "" || a.repeated || a.inflection != nil || g.BlockedWord(a.word()) {
+		return
+	}
+	g.GameState.Lock()
+	enabled := g.GameState.ChallengeTime > 0
+	g.GameState.Unlock()
+	if !enabled {
+		return
+	}
+
+	g.Lock()
+	defer g.Unlock()
+	if g.appeals == nil {
+		g.appeals = make(map[*Player]answer)
+	}
+	g.appeals[a.player] = a
+}
+
+// Challenging reports whether a challenge vote is open.
+func (g *game) Challenging() bool {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return g.GameState.Challenging
+}
+
+// Challenge opens a vote on the player's rejected answer from the last round.
+// Each answer can only be appealed once.
+func (g *game) Challenge(player *Player) error {
+	g.Lock()
+	over := g.over
+	a, ok := g.appeals[player]
+	voters := len(g.players) - 1
+	if !over && ok && voters > 0 && g.challenge == nil {
+		delete(g.appeals, player)
+		g.challenge = &challenge{
+			answer: a,
+			votes:  make(map[*Player]bool),
+			voters: voters,
+			voted:  make(chan struct{}),
+		}
+	}
+	current := g.challenge
+	g.Unlock()
+
+	switch {
+	case over:
+		return errors.New("the game is over")
+	case !ok:
+		return errors.New("no rejected answer to appeal")
+	case voters == 0:
+		return errors.New("nobody to vote on the appeal")
+	case current.answer.player != player:
+		return errors.New("another challenge is in progress")
+	}
+
+	g.GameState.Lock()
+	g.GameState.Challenging = true
+	challengeTime := g.GameState.ChallengeTime
+	g.GameState.Unlock()
+
+	data, _ := json.Marshal(&ChallengeStartResponse{
+		Username: player.Username,
+		Word:     a.word(),
+		Time:     challengeTime,
+	})
+	g.BroadcastMessage(CHALLENGE_START, data)
+
+	go g.runChallenge(current, challengeTime)
+	return nil
+}
+
+// Vote records a player's vote on the open challenge. Players can change
+// their vote until it closes.
+func (g *game) Vote(player *Player, accept bool) error {
+	g.Lock()
+	defer g.Unlock()
+	if g.challenge == nil {
+		return errors.New("no challenge in progress")
+	}
+	if g.challenge.answer.player == player {
+		return errors.New("player can't vote on their own appeal")
+	}
+
+	_, voted := g.challenge.votes[player]
+	g.challenge.votes[player] = accept
+	if !voted && len(g.challenge.votes) == g.challenge.voters {
+		close(g.challenge.voted)
+	}
+	return nil
+}
+
+// runChallenge waits for the vote to finish and announces the result. More
+// votes to accept than to reject overturn the decision.
+func (g *game) runChallenge(c *challenge, challengeTime int) {
+	timer := time.NewTimer(time.Duration(challengeTime) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-c.voted:
+	case <-timer.C:
+	}
+
+	g.Lock()
+	g.challenge = nil
+	over := g.over
+	var result ChallengeResultResponse
+	for _, accept := range c.votes {
+		if accept {
+			result.For++
+		} else {
+			result.Against++
+		}
+	}
+	g.Unlock()
+
+	g.GameState.Lock()
+	g.GameState.Challenging = false
+	save := g.GameState.ChallengeSave
+	g.GameState.Unlock()
+
+	a := c.answer
+	result.Username = a.player.Username
+	result.Word = a.word()
+	// The game may have ended, or the room gone back to the lobby, during
+	// the vote
+	result.Accepted = result.For > result.Against && !over && g.GetStarted()
+	if result.Accepted {
+		score := g.overturn(a)
+		result.Score = &score
+		result.Saved = g.saveAccepted(a.word(), save)
+	}
+
+	data, _ := json.Marshal(&result)
+	g.BroadcastMessage(CHALLENGE_RESULT, data)
+	if result.Accepted {
+		g.BroadcastGameState()
+		g.SendPlayerState(a.player)
+	}
+}
+
+// overturn scores a rejected answer as accepted, undoing everything the
+// rejection cost the player.
+func (g *game) overturn(a answer) oshirigame.ScoreBreakdown {
+	a.player.UndoLastResult(a.word())
+	a.player.SetPlayerScore(a.player.GetPlayerScore() - a.score)
+	if a.lostLife {
+		g.RestoreLife(a.player)
+	}
+
+	a.accepted = true
+	result := g.scoreAnswer(a, false)
+	g.MarkUsed(result.Word)
+	if g.GetMode() == ModeChain {
+		g.AddToChain(result.Word)
+	}
+	return result.Score
+}
+
+// saveAccepted keeps an overturned word accepted as the room chose, returning
+// where it was saved. Uploaded lists all share the custom language code, so
+// their words are only ever saved for the room.
+func (g *game) saveAccepted(word string, save string) string {
+	code := g.GetDictionary().Language().Code
+	if save == ChallengeSaveServer && code == oshirigame.Custom {
+		save = ChallengeSaveRoom
+	}
+	switch save {
+	case ChallengeSaveRoom:
+		g.GameState.Lock()
+		g.GameState.AcceptedWords[word] = true
+		g.GameState.Unlock()
+	case ChallengeSaveServer:
+		if err := oshirigame.AddSupplementary(code, word); err != nil {
+			log.Printf("error: saving supplementary word: %v", err)
+		}
+	default:
+		return ""
+	}
+	return save
+}
@@ -101,3 +101,13 @@ func (c *client) SetClientGameId(id string) {
 	defer c.Unlock()
 	c.gameId = id
 }
+
+func (c *client) SendError(message string) {
+	data, _ := json.Marshal(&ErrorResponse{
+		Message: message,
+	})
+	c.send <- &Message{
+		Type: ERROR,
+		Data: data,
+	}
+}
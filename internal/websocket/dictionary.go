@@ -0,0 +1,239 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/carl1330/oshirigame/internal/oshirigame"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+type WordCheckResponse struct {
+	Word       string `json:"word"`
+	Valid      bool   `json:"valid"`
+	Definition string `json:"definition,omitempty"`
+}
+
+type WordCountResponse struct {
+	Atama  string `json:"atama"`
+	Oshiri string `json:"oshiri"`
+	Count  int    `json:"count"`
+}
+
+type WordPageResponse struct {
+	Total  int      `json:"total"`
+	Offset int      `json:"offset"`
+	Words  []string `json:"words"`
+}
+
+type PairPageResponse struct {
+	Total  int                     `json:"total"`
+	Offset int                     `json:"offset"`
+	Pairs  []oshirigame.PairRating `json:"pairs"`
+}
+
+// CheckWord reports whether a single word is in the dictionary.
+func (h *handler) CheckWord(w http.ResponseWriter, r *http.Request) {
+	dictionary, ok := dictionaryParam(w, r)
+	if !ok {
+		return
+	}
+
+	word := dictionary.Normalize(chi.URLParam(r, "word"))
+	response := &WordCheckResponse{
+		Word:  word,
+		Valid: dictionary.IsValidWord(word) && !blockedForAPI(word),
+	}
+	if response.Valid {
+		response.Definition, _ = oshirigame.Define(word)
+	}
+	writeJSON(w, response)
+}
+
+// ListWords pages through the words for an atama and oshiri, optionally
+// limited to a length range. One of the two may be left out but not both, so
+// a request only ever reads the part of the index those letters select
+// rather than the whole dictionary.
+func (h *handler) ListWords(w http.ResponseWriter, r *http.Request) {
+	dictionary, ok := dictionaryParam(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	minLength, err := intParam(query.Get("minLength"), 0)
+	if err != nil {
+		http.Error(w, "invalid minLength", http.StatusBadRequest)
+		return
+	}
+	maxLength, err := intParam(query.Get("maxLength"), 0)
+	if err != nil {
+		http.Error(w, "invalid maxLength", http.StatusBadRequest)
+		return
+	}
+
+	atama := dictionary.Normalize(query.Get("atama"))
+	oshiri := dictionary.Normalize(query.Get("oshiri"))
+	if atama == "" && oshiri == "" {
+		http.Error(w, "atama or oshiri is required", http.StatusBadRequest)
+		return
+	}
+
+	writePage(w, r, dictionary.Words(atama, oshiri, minLength, maxLength))
+}
+
+// CountWords returns how many words an atama and oshiri pair allows.
+func (h *handler) CountWords(w http.ResponseWriter, r *http.Request) {
+	dictionary, ok := dictionaryParam(w, r)
+	if !ok {
+		return
+	}
+
+	atama := dictionary.Normalize(r.URL.Query().Get("atama"))
+	oshiri := dictionary.Normalize(r.URL.Query().Get("oshiri"))
+	writeJSON(w, &WordCountResponse{
+		Atama:  atama,
+		Oshiri: oshiri,
+		Count:  dictionary.WordCount(atama, oshiri),
+	})
+}
+
+// MatchWords pages through the words matching a glob pattern like a??le. Like
+// ListWords, the pattern has to start or end with a letter.
+func (h *handler) MatchWords(w http.ResponseWriter, r *http.Request) {
+	dictionary, ok := dictionaryParam(w, r)
+	if !ok {
+		return
+	}
+
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		http.Error(w, "missing pattern", http.StatusBadRequest)
+		return
+	}
+	pattern = dictionary.Normalize(pattern)
+	units := dictionary.Language().Split(pattern)
+	if len(units) == 0 || (oshirigame.IsWildcard(units[0]) && oshirigame.IsWildcard(units[len(units)-1])) {
+		http.Error(w, "pattern must start or end with a letter", http.StatusBadRequest)
+		return
+	}
+
+	writePage(w, r, dictionary.Match(pattern))
+}
+
+// ListPairs pages through the difficulty catalogue, easiest first, optionally
+// limited to one difficulty preset.
+func (h *handler) ListPairs(w http.ResponseWriter, r *http.Request) {
+	dictionary, ok := dictionaryParam(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	atamaLength, err := intParam(query.Get("atamaLength"), 1)
+	if err != nil || !validFragmentLength(atamaLength) {
+		http.Error(w, "invalid atamaLength", http.StatusBadRequest)
+		return
+	}
+	oshiriLength, err := intParam(query.Get("oshiriLength"), 1)
+	if err != nil || !validFragmentLength(oshiriLength) {
+		http.Error(w, "invalid oshiriLength", http.StatusBadRequest)
+		return
+	}
+	difficulty := query.Get("difficulty")
+	if difficulty == "" {
+		difficulty = oshirigame.DifficultyCustom
+	}
+	if !oshirigame.ValidDifficulty(difficulty) {
+		http.Error(w, "unknown difficulty", http.StatusBadRequest)
+		return
+	}
+
+	offset, limit, ok := pageBounds(w, r)
+	if !ok {
+		return
+	}
+	pairs := oshirigame.EligiblePairs(dictionary, atamaLength, oshiriLength, difficulty, 0)
+	page := &PairPageResponse{
+		Total:  len(pairs),
+		Offset: offset,
+		Pairs:  []oshirigame.PairRating{},
+	}
+	if offset < len(pairs) {
+		page.Pairs = pairs[offset:min(offset+limit, len(pairs))]
+	}
+	writeJSON(w, page)
+}
+
+func dictionaryParam(w http.ResponseWriter, r *http.Request) (oshirigame.Dictionary, bool) {
+	dictionary, err := oshirigame.GetDictionary(chi.URLParam(r, "language"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	return dictionary, true
+}
+
+// blockedForAPI keeps the query API as clean as a room on the standard
+// filter level.
+func blockedForAPI(word string) bool {
+	return oshirigame.GetBlocklist().BlocksWord(word, oshirigame.FilterStandard)
+}
+
+// pageBounds reads the offset and limit of a paged request.
+func pageBounds(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	offset, err := intParam(r.URL.Query().Get("offset"), 0)
+	if err != nil || offset < 0 {
+		http.Error(w, "invalid offset", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	limit, err := intParam(r.URL.Query().Get("limit"), defaultPageSize)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return offset, limit, true
+}
+
+func writePage(w http.ResponseWriter, r *http.Request, words []string) {
+	offset, limit, ok := pageBounds(w, r)
+	if !ok {
+		return
+	}
+
+	allowed := make([]string, 0, len(words))
+	for _, word := range words {
+		if !blockedForAPI(word) {
+			allowed = append(allowed, word)
+		}
+	}
+
+	page := &WordPageResponse{
+		Total:  len(allowed),
+		Offset: offset,
+		Words:  []string{},
+	}
+	if offset < len(allowed) {
+		page.Words = allowed[offset:min(offset+limit, len(allowed))]
+	}
+	writeJSON(w, page)
+}
+
+func intParam(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	data, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -16,29 +17,69 @@ type game struct {
 	Id          string `json:"id"`
 	players     map[string]*Player
 	GameState   *GameState
-	WordList    *oshirigame.WordList
+	Dictionary  oshirigame.Dictionary
 	register    chan *Player
 	unregister  chan *client
+	remaining   chan int // How many players are left after an unregister
 	broadcast   chan *Message
+	done        chan struct{} // Closed when the room is removed
 	running     bool
 	roundCtx    context.Context
 	cancelRound context.CancelFunc
+	seed        int64 // Chosen by the host, or 0 for a new seed every game
+	rng         *rand.Rand
+	steals      chan answer // Open while the rest of the room can steal a failed pair
+	stealFrom   *Player
+	appeals     map[*Player]answer // Rejected answers from the last round that can be challenged
+	challenge   *challenge
+	over        bool // Set once the game has ended, until the next one starts
 	sync.Mutex
 }
 
 type GameState struct {
-	Started          bool      `json:"started"`
-	Round            int       `json:"round"`
-	MaxRounds        int       `json:"maxRounds"`
-	Time             int       `json:"time"`
-	RoundTime        int       `json:"roundTime"`
-	WordCombinations int       `json:"wordCombinations"`
-	PlayerQueue      []*Player `json:"playerQueue"`
-	Input            string    `json:"input"`
-	Atama            string    `json:"atama"`
-	Oshiri           string    `json:"oshiri"`
-	RoundOver        bool      `json:"roundOver"`
-	TurnCount        int       `json:"-"` // Track turns in current round, not sent to client
+	Started          bool                 `json:"started"`
+	Round            int                  `json:"round"`
+	MaxRounds        int                  `json:"maxRounds"`
+	Time             int                  `json:"time"`
+	RoundTime        int                  `json:"roundTime"`
+	WordCombinations int                  `json:"wordCombinations"`
+	Seed             int64                `json:"seed"`       // Seed of the letters in the current game
+	Difficulty       string               `json:"difficulty"` // A preset, or custom to use WordCombinations
+	AtamaPool        string               `json:"atamaPool"`
+	OshiriPool       string               `json:"oshiriPool"`
+	ExcludedLetters  []string             `json:"excludedLetters"`
+	LetterSource     string               `json:"letterSource"`
+	Bag              oshirigame.LetterBag `json:"bag,omitempty"` // Tiles left in a letter bag game
+	AtamaLength      int                  `json:"atamaLength"`
+	OshiriLength     int                  `json:"oshiriLength"`
+	Language         string               `json:"language"`
+	Filter           string               `json:"filter"`
+	Scoring          string               `json:"scoring"`
+	Inflection       string               `json:"inflection"`
+	ScoringFormula   string               `json:"scoringFormula"`
+	PlayerQueue      []*Player            `json:"playerQueue"`
+	Spectators       []*Player            `json:"spectators"` // Players knocked out of an elimination game
+	Input            string               `json:"input"`
+	Atama            string               `json:"atama"`
+	Oshiri           string               `json:"oshiri"`
+	RoundOver        bool                 `json:"roundOver"`
+	Answering        bool                 `json:"answering"` // Between ROUND_START and the end of the round timer
+	Mode             string               `json:"mode"`
+	ChainOshiri      string               `json:"chainOshiri"`
+	Chain            []string             `json:"chain,omitempty"` // Accepted words in chain mode, in order
+	UsedWords        map[string]bool      `json:"-"`               // Accepted words, sent in GAME_OVER
+	NoRepeat         string               `json:"noRepeat"`
+	Teams            int                  `json:"teams"`     // 1 plays without teams
+	Lives            int                  `json:"lives"`     // Lives each player starts with, 0 without elimination
+	StealTime        int                  `json:"stealTime"` // Seconds others get to steal a failed pair, 0 without stealing
+	Stealing         bool                 `json:"stealing"`
+	ChallengeTime    int                  `json:"challengeTime"` // Seconds to vote on an appeal, 0 without appeals
+	ChallengeSave    string               `json:"challengeSave"`
+	Challenging      bool                 `json:"challenging"`
+	AcceptedWords    map[string]bool      `json:"-"` // Words the room voted in
+	RepeatPenalty    string               `json:"repeatPenalty"`
+	TurnCount        int                  `json:"-"` // Track turns in current round, not sent to client
+	InputTime        int                  `json:"-"` // Time left when the input last changed
 	sync.Mutex
 }
 
@@ -47,26 +88,45 @@ type Player struct {
 	IsLeader bool   `json:"isLeader"`
 	Username string `json:"username"`
 	Score    int    `json:"score"`
-	client   *client
+	// Answered tells the room a player has an answer in simultaneous play
+	// without giving it away.
+	Answered   bool `json:"answered"`
+	Team       int  `json:"team,omitempty"` // 1 and up, or 0 without teams
+	Lives      int  `json:"lives,omitempty"`
+	Eliminated bool `json:"eliminated,omitempty"`
+	client     *client
+	history    []oshirigame.RoundResult
+	input      string
+	inputTime  int
 	sync.Mutex
 }
 
-func NewGame() *game {
+func NewGame() (*game, error) {
+	dictionary, err := oshirigame.GetDictionary(oshirigame.English)
+	if err != nil {
+		return nil, err
+	}
+
 	haikunator := haikunator.New()
 	haikunator.TokenLength = 0
+	seed := newSeed()
 	return &game{
 		Id:         haikunator.Haikunate(),
 		broadcast:  make(chan *Message),
 		register:   make(chan *Player),
 		unregister: make(chan *client),
+		remaining:  make(chan int),
+		done:       make(chan struct{}),
 		players:    make(map[string]*Player),
 		GameState:  NewGameState(),
-		WordList:   oshirigame.NewWordList(),
+		Dictionary: dictionary,
 		running:    false,
-	}
+		rng:        newRand(seed),
+	}, nil
 }
 
 func NewGameState() *GameState {
+	scoring, _ := oshirigame.GetScoringStrategy(oshirigame.ScoringClassic)
 	return &GameState{
 		Started:          false,
 		Round:            1,
@@ -74,7 +134,28 @@ func NewGameState() *GameState {
 		Time:             0,
 		RoundTime:        25,
 		WordCombinations: 400,
+		Difficulty:       oshirigame.DifficultyCustom,
+		AtamaPool:        oshirigame.PoolUniform,
+		OshiriPool:       oshirigame.PoolUniform,
+		ExcludedLetters:  []string{},
+		LetterSource:     oshirigame.LetterSourcePool,
+		Mode:             ModeTurns,
+		ChainOshiri:      ChainOshiriRandom,
+		UsedWords:        make(map[string]bool),
+		NoRepeat:         NoRepeatOff,
+		Teams:            1,
+		ChallengeSave:    ChallengeSaveOff,
+		AcceptedWords:    make(map[string]bool),
+		RepeatPenalty:    RepeatReject,
+		AtamaLength:      1,
+		OshiriLength:     1,
+		Language:         oshirigame.English,
+		Filter:           oshirigame.FilterStandard,
+		Scoring:          scoring.Name(),
+		Inflection:       oshirigame.InflectionAllow,
+		ScoringFormula:   scoring.Formula(),
 		PlayerQueue:      make([]*Player, 0),
+		Spectators:       make([]*Player, 0),
 	}
 }
 
@@ -110,6 +191,13 @@ func (g *game) Dequeue() *Player {
 	return player
 }
 
+// QueuedPlayers returns the players in queue order.
+func (g *game) QueuedPlayers() []*Player {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return append([]*Player(nil), g.GameState.PlayerQueue...)
+}
+
 func (g *game) RemovePlayer(token string) {
 	g.Lock()
 	defer g.Unlock()
@@ -125,6 +213,12 @@ func (g *game) RemovePlayer(token string) {
 			}
 		}
 	}
+	for i, player := range g.GameState.Spectators {
+		if player.token == token {
+			g.GameState.Spectators = append(g.GameState.Spectators[:i:i], g.GameState.Spectators[i+1:]...)
+			break
+		}
+	}
 	delete(g.players, token)
 }
 
@@ -144,9 +238,23 @@ func (g *game) GetStarted() bool {
 }
 
 func (g *game) InitializeGame() {
+	g.SeedLetters()
+	g.ClearChain()
+	g.ResetLives()
+	g.AssignTeams()
+	g.AlternateTeams()
+	rng := g.Rand()
+	settings := g.PairSettings()
+	atamaPool, oshiriPool := settings.LetterPools()
+	if settings.letterSource == oshirigame.LetterSourceBag {
+		g.SetBag(oshirigame.NewLetterBag(settings.dictionary, settings.excludedLetters))
+	} else {
+		g.SetBag(nil)
+	}
+	g.setOver(false)
 	g.SetGameStarted(true)
-	g.SetAtama(RandomLetter())
-	g.SetOshiri(RandomLetter())
+	g.SetAtama(atamaPool.Pick(rng))
+	g.SetOshiri(oshiriPool.Pick(rng))
 	g.SetGameStateTime(g.GameState.RoundTime)
 	g.SetGameStateInput("")
 	g.SetRoundOver(false)
@@ -167,23 +275,57 @@ func (g *game) StartRound() {
 	g.SetGameStarted(true)
 	g.SetGameRunning(true)
 	g.SetRoundOver(false)
+	g.SetAnswering(false)
+	// Appeals only last until the next round
+	g.clearAppeals()
 	g.SetGameStateTime(g.GameState.RoundTime)
 	g.SetGameStateInput("")
 
-	//Generate random letters and check if that combination of letters has more than 400 possible words
-	//If not try again until successful
-	for {
-		g.SetAtama(RandomLetter())
-		g.SetOshiri(RandomLetter())
-		if g.WordList.WordCount(g.GameState.Atama, g.GameState.Oshiri) >= g.GameState.WordCombinations {
-			break
-		}
+	// Custom word lists are often too small for the default, so ask for no
+	// more combinations than the list can give.
+	if g.GetDictionary().Language().Code == oshirigame.Custom {
+		g.LimitWordCombinations()
 	}
 
+	settings := g.PairSettings()
+	dictionary := settings.dictionary
+	if settings.letterSource == oshirigame.LetterSourceBag {
+		settings.bag = settings.Bag()
+	}
+	var atama, oshiri string
+	var ok bool
+	if link := g.ChainLink(); link != "" {
+		atama, oshiri, ok = settings.PickChainPair(g.Rand(), link)
+	}
+	// The first word of a chain, and chains that hit a dead end, start
+	// from a random pair.
+	if !ok {
+		atama, oshiri, ok = settings.PickPair(g.Rand())
+	}
+	if !ok && settings.letterSource == oshirigame.LetterSourceBag {
+		// Like Scrabble, the game ends when the tiles do.
+		g.SetGameRunning(false)
+		g.EndGame("The letter bag ran out of pairs")
+		return
+	}
+	if !ok {
+		data, _ := json.Marshal(&ErrorResponse{
+			Message: "No atama and oshiri fit the room's settings",
+		})
+		g.BroadcastMessage(ERROR, data)
+		g.ResetToLobby()
+		return
+	}
+
+	g.SetAtama(atama)
+	g.SetOshiri(oshiri)
+	g.SetBag(settings.bag)
+
 	// Wait for letter timer or cancellation
 	select {
 	case <-letterTimer.C:
-		g.BroadcastMessage(ROUND_ATAMA, json.RawMessage(`{"letter":"`+g.GameState.Atama+`"}`))
+		data, _ := json.Marshal(NewLetterResponse(dictionary, atama))
+		g.BroadcastMessage(ROUND_ATAMA, data)
 	case <-ctx.Done():
 		return // Round cancelled
 	}
@@ -191,11 +333,13 @@ func (g *game) StartRound() {
 	letterTimer.Reset(3 * time.Second)
 	select {
 	case <-letterTimer.C:
-		g.BroadcastMessage(ROUND_OSHIRI, json.RawMessage(`{"letter":"`+g.GameState.Oshiri+`"}`))
+		data, _ := json.Marshal(NewLetterResponse(dictionary, oshiri))
+		g.BroadcastMessage(ROUND_OSHIRI, data)
 	case <-ctx.Done():
 		return // Round cancelled
 	}
 
+	g.SetAnswering(true)
 	data := g.MarsalGameState()
 	g.BroadcastMessage(ROUND_START, data)
 
@@ -210,36 +354,103 @@ func (g *game) StartRound() {
 		}
 	}
 
-	g.FinishRound()
+	g.SetAnswering(false)
+	g.FinishRound(ctx)
 }
 
-func (g *game) FinishRound() {
+// FinishRound scores the round. The round's context is passed on to the
+// steal window so it closes when the round is cancelled.
+func (g *game) FinishRound(ctx context.Context) {
 	// Check if game is still running (not cancelled)
 	if !g.IsRunning() {
 		return
 	}
 
 	if len(g.players) > 0 {
-		player := g.Dequeue()
-		player.SetPlayerScore(player.GetPlayerScore() + g.WordList.GetScore(g.GameState.Atama+g.GameState.Input+g.GameState.Oshiri))
-
-		g.Enqueue(player)
-
-		// Increment turn counter
-		g.GameState.Lock()
-		g.GameState.TurnCount++
-		totalPlayers := len(g.players)
-		// A round completes when everyone has had exactly one turn
-		wasLastPlayer := g.GameState.TurnCount >= totalPlayers
-		if wasLastPlayer {
-			g.GameState.TurnCount = 0 // Reset for next round
+		dictionary := g.GetDictionary()
+		var roundOverResponse RoundOverResponse
+		words := make([]string, 0)
+
+		var wasLastPlayer bool
+		if g.GetMode() == ModeSimultaneous {
+			// Everyone played the same pair, so the round is always complete
+			results, answers := g.judgeAll(dictionary)
+			roundOverResponse.Results = results
+			roundOverResponse.Eliminated = g.LoseLives(answers)
+			for _, a := range answers {
+				g.addAppeal(a)
+			}
+			for _, result := range roundOverResponse.Results {
+				words = append(words, result.Word)
+			}
+			wasLastPlayer = true
+		} else {
+			g.GameState.Lock()
+			input, inputTime := g.GameState.Input, g.GameState.InputTime
+			g.GameState.Unlock()
+
+			player := g.Dequeue()
+			answer := g.judge(dictionary, player, input, inputTime)
+			result := g.scoreAnswer(answer, false)
+			answer.score = result.Score.Total
+			// The active player always loses a life for a rejected word
+			answer.lostLife = !answer.accepted && g.Elimination()
+			eliminated := !answer.accepted && g.LoseLife(player)
+			g.addAppeal(answer)
+			if eliminated {
+				roundOverResponse.Eliminated = []string{player.Username}
+			} else {
+				g.Enqueue(player)
+			}
+			if answer.accepted {
+				g.MarkUsed(result.Word)
+			}
+			if answer.accepted && g.GetMode() == ModeChain {
+				g.AddToChain(result.Word)
+			}
+			if !answer.accepted {
+				if stolen, ok := g.stealRound(ctx, player); ok {
+					steal := g.scoreAnswer(stolen, false)
+					roundOverResponse.Steal = &steal
+					g.MarkUsed(steal.Word)
+					if g.GetMode() == ModeChain {
+						g.AddToChain(steal.Word)
+					}
+					words = append(words, steal.Word)
+				}
+				// The room went back to the lobby during the steal
+				if !g.IsRunning() {
+					return
+				}
+			}
+
+			// Increment turn counter. An eliminated player's turn no longer
+			// counts, as they have also left the queue.
+			g.GameState.Lock()
+			if !eliminated {
+				g.GameState.TurnCount++
+			}
+			totalPlayers := len(g.GameState.PlayerQueue)
+			// A round completes when everyone still playing has had exactly one turn
+			wasLastPlayer = g.GameState.TurnCount >= totalPlayers
+			if wasLastPlayer {
+				g.GameState.TurnCount = 0 // Reset for next round
+			}
+			g.GameState.Unlock()
+
+			roundOverResponse.Word = result.Word
+			roundOverResponse.WordAccepted = result.WordAccepted
+			roundOverResponse.Score = result.Score
+			roundOverResponse.Inflection = result.Inflection
+			roundOverResponse.Suggestions = result.Suggestions
+			roundOverResponse.Repeated = result.Repeated
+			words = append(words, result.Word)
 		}
-		g.GameState.Unlock()
 
-		var roundOverResponse RoundOverResponse
-		roundOverResponse.TopWords = g.WordList.TopWords(g.GameState.Atama, g.GameState.Oshiri)
-		roundOverResponse.Word = g.GameState.Atama + g.GameState.Input + g.GameState.Oshiri
-		roundOverResponse.WordAccepted = g.WordList.IsValidWord(g.GameState.Atama + g.GameState.Input + g.GameState.Oshiri)
+		roundOverResponse.TopWords = dictionary.TopWords(g.GameState.Atama, g.GameState.Oshiri, func(word string) bool {
+			return g.BlockedWord(word) || (g.RejectsRepeats() && g.UsedInRoom(word))
+		})
+		roundOverResponse.Definitions = oshirigame.DefineAll(append(words, roundOverResponse.TopWords...)...)
 
 		g.SetRoundOver(true)
 
@@ -257,8 +468,10 @@ func (g *game) FinishRound() {
 
 		// Check if game is over (max rounds reached) before incrementing for next round
 		// If this was the last player and we've reached max rounds, end the game
-		if wasLastPlayer && g.IsGameOver() {
-			g.EndGame()
+		if reason, over := g.LastStanding(); over {
+			g.EndGame(reason)
+		} else if wasLastPlayer && g.IsGameOver() {
+			g.EndGame("")
 		} else if wasLastPlayer {
 			// Only increment round when we've completed a full cycle (back to the first player)
 			g.IncrementRound()
@@ -267,18 +480,155 @@ func (g *game) FinishRound() {
 	}
 }
 
+// answer is a player's input for the round, judged but not yet scored.
+type answer struct {
+	player     *Player
+	atama      string
+	input      string
+	oshiri     string
+	accepted   bool
+	inflection *InflectionResponse
+	lemma      string
+	timeLeft   int
+	timeLimit  int // The seconds the answer had, when not the round's
+	repeated   bool
+	score      int  // Points the answer was scored, so an appeal can undo them
+	lostLife   bool // Whether the answer cost the player a life
+}
+
+func (a answer) word() string {
+	return a.atama + a.input + a.oshiri
+}
+
+// judge decides whether a player's input makes an acceptable word.
+func (g *game) judge(dictionary oshirigame.Dictionary, player *Player, input string, timeLeft int) answer {
+	g.GameState.Lock()
+	a := answer{
+		player:   player,
+		atama:    g.GameState.Atama,
+		input:    dictionary.Normalize(input),
+		oshiri:   g.GameState.Oshiri,
+		timeLeft: timeLeft,
+	}
+	g.GameState.Unlock()
+
+	word := a.word()
+	a.accepted = (dictionary.IsValidWord(word) || g.Supplementary(dictionary, word)) && !g.BlockedWord(word)
+	if a.accepted && g.IsRepeat(player, word) {
+		a.repeated = true
+		a.accepted = !g.RejectsRepeats()
+	}
+	lemma, inflected := dictionary.Lemma(word)
+	if a.accepted && inflected {
+		a.inflection = g.ApplyInflection(lemma)
+		a.accepted = a.inflection.Decision != InflectionRejected
+		if a.inflection.Decision == InflectionScoredAsLemma {
+			a.lemma = lemma
+		}
+	}
+	return a
+}
+
+// uniqueAnswerBonus is added in simultaneous play for an accepted word nobody
+// else found.
+const uniqueAnswerBonus = 2
+
+// scoreAnswer scores an answer with the room's strategy and adds the points
+// to the player.
+func (g *game) scoreAnswer(a answer, unique bool) PlayerResult {
+	dictionary := g.GetDictionary()
+	g.GameState.Lock()
+	roundTime := g.GameState.RoundTime
+	if a.timeLimit > 0 {
+		roundTime = a.timeLimit
+	}
+	round := oshirigame.RoundContext{
+		Dictionary: dictionary,
+		Atama:      a.atama,
+		Input:      a.input,
+		Oshiri:     a.oshiri,
+		Accepted:   a.accepted,
+		TimeLeft:   a.timeLeft,
+		RoundTime:  roundTime,
+		History:    a.player.GetHistory(),
+		Lemma:      a.lemma,
+	}
+	g.GameState.Unlock()
+
+	score := g.GetScoringStrategy().Score(round)
+	if a.repeated && a.accepted {
+		// Repeats the room lets through are worth nothing.
+		score = oshirigame.ScoreBreakdown{}
+	}
+	if unique {
+		score.Add("Unique answer", uniqueAnswerBonus)
+	}
+	a.player.SetPlayerScore(a.player.GetPlayerScore() + score.Total)
+	a.player.AddHistory(oshirigame.RoundResult{
+		Word:     a.word(),
+		Accepted: a.accepted,
+		Score:    score.Total,
+	})
+
+	result := PlayerResult{
+		Username:     a.player.Username,
+		Word:         a.word(),
+		WordAccepted: a.accepted,
+		Score:        score,
+		Inflection:   a.inflection,
+		Unique:       unique,
+		Repeated:     a.repeated,
+	}
+	if !a.accepted && a.input != "" {
+		result.Suggestions = dictionary.Suggest(a.atama, a.oshiri, a.word(), g.BlockedWord)
+	}
+	return result
+}
+
+// judgeAll judges and scores every player's private answer in simultaneous
+// play, in queue order.
+func (g *game) judgeAll(dictionary oshirigame.Dictionary) ([]PlayerResult, []answer) {
+	var answers []answer
+	found := make(map[string]int)
+	for _, player := range g.QueuedPlayers() {
+		input, inputTime := player.GetInput()
+		a := g.judge(dictionary, player, input, inputTime)
+		if a.accepted {
+			found[a.word()]++
+		}
+		answers = append(answers, a)
+	}
+
+	results := make([]PlayerResult, 0, len(answers))
+	for i, a := range answers {
+		unique := a.accepted && !a.repeated && found[a.word()] == 1 && len(answers) > 1
+		result := g.scoreAnswer(a, unique)
+		answers[i].score = result.Score.Total
+		results = append(results, result)
+	}
+	for _, a := range answers {
+		if a.accepted {
+			g.MarkUsed(a.word())
+		}
+	}
+	return results, answers
+}
+
 func (g *game) Run() {
 	for {
 		select {
 		case player := <-g.register:
 			if _, ok := g.players[player.client.token]; !ok {
+				g.StartLives(player)
 				g.Enqueue(player)
+				g.AssignTeams()
 			}
 			g.players[player.client.token] = player
 			go g.BroadcastGameState()
 			go g.SendPlayerState(player)
 		case player := <-g.unregister:
 			g.RemovePlayer(player.token)
+			g.remaining <- len(g.players)
 			go g.BroadcastGameState()
 		case message := <-g.broadcast:
 			for _, player := range g.players {
@@ -286,10 +636,24 @@ func (g *game) Run() {
 				player.client.send <- message
 				player.Unlock()
 			}
+		case <-g.done:
+			return
 		}
 	}
 }
 
+// Close stops a room nobody is left in. The round is cancelled, Run returns
+// and any later broadcasts are dropped.
+func (g *game) Close() {
+	g.Lock()
+	if g.cancelRound != nil {
+		g.cancelRound()
+	}
+	g.Unlock()
+	g.SetGameRunning(false)
+	close(g.done)
+}
+
 func (g *game) BroadcastGameState() {
 	g.GameState.Lock()
 	data, _ := json.Marshal(g.GameState)
@@ -298,7 +662,10 @@ func (g *game) BroadcastGameState() {
 		Type: GAME_STATE,
 		Data: data,
 	}
-	g.broadcast <- gameState
+	select {
+	case g.broadcast <- gameState:
+	case <-g.done:
+	}
 }
 
 func (g *game) SendPlayerState(player *Player) {
@@ -310,9 +677,70 @@ func (g *game) SendPlayerState(player *Player) {
 	player.client.send <- message
 }
 
-func RandomLetter() string {
-	var letters = []rune("abcdefghijklmnopqrstuvwxyz")
-	return string(letters[rand.Intn(len(letters))])
+// maxSeed keeps generated seeds exact when clients read them as JavaScript
+// numbers.
+const maxSeed = 1 << 53
+
+func newSeed() int64 {
+	return rand.Int63n(maxSeed-1) + 1
+}
+
+// lockedSource lets the round goroutine and the hub draw from the same game
+// generator.
+type lockedSource struct {
+	source rand.Source
+	sync.Mutex
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.source.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.Lock()
+	defer s.Unlock()
+	s.source.Seed(seed)
+}
+
+func newRand(seed int64) *rand.Rand {
+	return rand.New(&lockedSource{source: rand.NewSource(seed)})
+}
+
+// Rand is the game's own generator, so that a seed replays the same letters.
+func (g *game) Rand() *rand.Rand {
+	g.Lock()
+	defer g.Unlock()
+	return g.rng
+}
+
+// SetSeed fixes the seed every game in the room starts from. Zero goes back
+// to a fresh seed for every game.
+func (g *game) SetSeed(seed int64) {
+	g.Lock()
+	g.seed = seed
+	g.Unlock()
+
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Seed = seed
+}
+
+// SeedLetters restarts the generator for a new game from the host's seed,
+// or from a fresh one when the host did not choose any.
+func (g *game) SeedLetters() {
+	g.Lock()
+	seed := g.seed
+	if seed == 0 {
+		seed = newSeed()
+	}
+	g.rng = newRand(seed)
+	g.Unlock()
+
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Seed = seed
 }
 
 func (g *game) IsRunning() bool {
@@ -326,7 +754,10 @@ func (g *game) BroadcastMessage(messageType string, data json.RawMessage) {
 		Type: messageType,
 		Data: data,
 	}
-	g.broadcast <- message
+	select {
+	case g.broadcast <- message:
+	case <-g.done:
+	}
 }
 
 func (g *game) SendGameState(c *client) {
@@ -348,10 +779,23 @@ func (g *game) SetRoundOver(roundOver bool) {
 	g.GameState.RoundOver = roundOver
 }
 
-func (g *game) SetGameStateInput(input string) {
+// SetAnswering opens or closes the round to input. Letters are revealed
+// before ROUND_START, and input sent then would get the full speed bonus.
+func (g *game) SetAnswering(answering bool) {
 	g.GameState.Lock()
 	defer g.GameState.Unlock()
+	g.GameState.Answering = answering
+}
+
+func (g *game) SetGameStateInput(input string) {
+	g.GameState.Lock()
 	g.GameState.Input = input
+	g.GameState.InputTime = 0
+	g.GameState.Unlock()
+
+	for _, player := range g.QueuedPlayers() {
+		player.SetInput(input, 0)
+	}
 }
 
 func (g *game) SetGameStateTime(time int) {
@@ -400,28 +844,45 @@ func (g *game) IncrementRound() {
 func (g *game) IsGameOver() bool {
 	g.GameState.Lock()
 	defer g.GameState.Unlock()
-	return g.GameState.Round >= g.GameState.MaxRounds
+	// Elimination games run until one player is left
+	return g.GameState.Lives == 0 && g.GameState.Round >= g.GameState.MaxRounds
 }
 
-func (g *game) EndGame() {
+// EndGame ranks the players and announces the end of the game, with reason
+// set when it ended before the last round.
+func (g *game) EndGame(reason string) {
+	// Points can't change once the game is over
+	g.setOver(true)
+	g.clearAppeals()
+
 	// Calculate winners by sorting players by score
 	type playerScore struct {
 		username string
 		score    int
+		words    []string
+		survival int
 	}
 
+	perPlayer := g.GetNoRepeat() == NoRepeatPlayer
 	var scores []playerScore
 	for _, player := range g.players {
-		scores = append(scores, playerScore{
+		ps := playerScore{
 			username: player.Username,
 			score:    player.GetPlayerScore(),
-		})
+			survival: g.survival(player),
+		}
+		if perPlayer {
+			ps.words = player.PlayedWords()
+		}
+		scores = append(scores, ps)
 	}
 
-	// Sort by score descending
+	// Sort by score descending, after how long players survived in
+	// elimination games
 	for i := 0; i < len(scores); i++ {
 		for j := i + 1; j < len(scores); j++ {
-			if scores[j].score > scores[i].score {
+			if scores[j].survival > scores[i].survival ||
+				(scores[j].survival == scores[i].survival && scores[j].score > scores[i].score) {
 				scores[i], scores[j] = scores[j], scores[i]
 			}
 		}
@@ -432,18 +893,25 @@ func (g *game) EndGame() {
 	currentRank := 1
 	for i, ps := range scores {
 		// If not first player and score is different from previous, increment rank
-		if i > 0 && ps.score != scores[i-1].score {
+		if i > 0 && (ps.score != scores[i-1].score || ps.survival != scores[i-1].survival) {
 			currentRank = i + 1
 		}
 		winners = append(winners, PlayerRanking{
-			Username: ps.username,
-			Score:    ps.score,
-			Rank:     currentRank,
+			Username:  ps.username,
+			Score:     ps.score,
+			Rank:      currentRank,
+			UsedWords: ps.words,
 		})
 	}
 
 	var gameOverResponse GameOverResponse
 	gameOverResponse.Winners = winners
+	gameOverResponse.Reason = reason
+	g.GameState.Lock()
+	gameOverResponse.Seed = g.GameState.Seed
+	g.GameState.Unlock()
+	gameOverResponse.UsedWords = g.UsedWords()
+	gameOverResponse.Teams = g.TeamRankings()
 
 	data, _ := json.Marshal(gameOverResponse)
 	g.BroadcastMessage(GAME_OVER, data)
@@ -473,13 +941,19 @@ func (g *game) ResetToLobby() {
 	g.GameState.Atama = ""
 	g.GameState.Oshiri = ""
 	g.GameState.RoundOver = false
+	g.GameState.Answering = false
 	g.GameState.TurnCount = 0
+	g.GameState.Bag = nil
+	g.GameState.Chain = nil
+	g.GameState.UsedWords = make(map[string]bool)
 	g.GameState.Unlock()
+	g.ResetLives()
 
 	// Reset all player scores but keep them in the game
 	g.Lock()
 	for _, player := range g.players {
 		player.SetPlayerScore(0)
+		player.ClearHistory()
 	}
 	g.Unlock()
 
@@ -498,12 +972,285 @@ func (g *game) SetMaxRounds(maxRounds int) {
 	g.GameState.MaxRounds = maxRounds
 }
 
+// SetLetterPools changes how atama and oshiri are drawn. An empty pool leaves
+// that side unchanged.
+func (g *game) SetLetterPools(atamaPool string, oshiriPool string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if atamaPool != "" {
+		g.GameState.AtamaPool = atamaPool
+	}
+	if oshiriPool != "" {
+		g.GameState.OshiriPool = oshiriPool
+	}
+}
+
+// No-repeat rules a room can choose between.
+const (
+	NoRepeatOff = "off"
+	// NoRepeatGame lets no word be played twice in a game.
+	NoRepeatGame = "game"
+	// NoRepeatPlayer lets no player play the same word twice in a game.
+	NoRepeatPlayer = "player"
+)
+
+// What happens to a word the no-repeat rule catches.
+const (
+	RepeatReject = "reject"
+	RepeatZero   = "zero"
+)
+
+func validNoRepeat(rule string) bool {
+	return rule == NoRepeatOff || rule == NoRepeatGame || rule == NoRepeatPlayer
+}
+
+func validRepeatPenalty(penalty string) bool {
+	return penalty == RepeatReject || penalty == RepeatZero
+}
+
+// SetNoRepeat changes the no-repeat rule and what it does to repeats. Empty
+// values leave the setting unchanged.
+func (g *game) SetNoRepeat(rule string, penalty string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if rule != "" {
+		g.GameState.NoRepeat = rule
+	}
+	if penalty != "" {
+		g.GameState.RepeatPenalty = penalty
+	}
+}
+
+func (g *game) GetNoRepeat() string {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return g.GameState.NoRepeat
+}
+
+// MarkUsed records an accepted word.
+func (g *game) MarkUsed(word string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.UsedWords[word] = true
+}
+
+// UsedInRoom reports whether a word was already accepted in this game and
+// the room counts that against everyone. Chain mode always does.
+func (g *game) UsedInRoom(word string) bool {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if g.GameState.Mode != ModeChain && g.GameState.NoRepeat != NoRepeatGame {
+		return false
+	}
+	return g.GameState.UsedWords[word]
+}
+
+// IsRepeat reports whether the room's rules count word as a repeat for player.
+func (g *game) IsRepeat(player *Player, word string) bool {
+	if g.UsedInRoom(word) {
+		return true
+	}
+	return g.GetNoRepeat() == NoRepeatPlayer && player.HasPlayed(word)
+}
+
+// RejectsRepeats reports whether repeats are rejected rather than scored at
+// zero. Chain mode always rejects them.
+func (g *game) RejectsRepeats() bool {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return g.GameState.Mode == ModeChain || g.GameState.RepeatPenalty == RepeatReject
+}
+
+// UsedWords lists every word accepted in this game, alphabetically.
+func (g *game) UsedWords() []string {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	words := make([]string, 0, len(g.GameState.UsedWords))
+	for word := range g.GameState.UsedWords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Modes a room can play in.
+const (
+	// ModeTurns has players take turns answering while the others watch.
+	ModeTurns = "turns"
+	// ModeSimultaneous has everyone answer the same pair in private.
+	ModeSimultaneous = "simultaneous"
+	// ModeChain takes turns like shiritori, each accepted word giving the
+	// next player their atama.
+	ModeChain = "chain"
+)
+
+func validMode(mode string) bool {
+	return mode == ModeTurns || mode == ModeSimultaneous || mode == ModeChain
+}
+
+func (g *game) SetMode(mode string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Mode = mode
+}
+
+func (g *game) GetMode() string {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return g.GameState.Mode
+}
+
+func (g *game) SetLetterSource(source string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.LetterSource = source
+}
+
+// SetBag replaces the tiles left in the game's letter bag.
+func (g *game) SetBag(bag oshirigame.LetterBag) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Bag = bag
+}
+
+func (g *game) SetExcludedLetters(letters []string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.ExcludedLetters = letters
+}
+
+func (g *game) SetDifficulty(difficulty string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Difficulty = difficulty
+}
+
 func (g *game) SetWordCombinations(min int) {
 	g.GameState.Lock()
 	defer g.GameState.Unlock()
 	g.GameState.WordCombinations = min
 }
 
+func (g *game) GetDictionary() oshirigame.Dictionary {
+	g.Lock()
+	defer g.Unlock()
+	return g.Dictionary
+}
+
+func (g *game) SetDictionary(dictionary oshirigame.Dictionary) {
+	g.Lock()
+	g.Dictionary = dictionary
+	g.Unlock()
+
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Language = dictionary.Language().Code
+}
+
+// LimitWordCombinations lowers WordCombinations to the most any pair in the
+// room's dictionary can satisfy and returns the resulting value.
+func (g *game) LimitWordCombinations() int {
+	dictionary := g.GetDictionary()
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	supported := dictionary.MaxWordCount(g.GameState.AtamaLength, g.GameState.OshiriLength)
+	if g.GameState.WordCombinations > supported {
+		g.GameState.WordCombinations = supported
+	}
+	return g.GameState.WordCombinations
+}
+
+// DiscardCustomDictionary drops a host-uploaded word list so it can be
+// garbage collected, putting the shared English dictionary back in its place.
+func (g *game) DiscardCustomDictionary() {
+	if g.GetDictionary().Language().Code != oshirigame.Custom {
+		return
+	}
+	dictionary, err := oshirigame.GetDictionary(oshirigame.English)
+	if err != nil {
+		return
+	}
+	g.SetDictionary(dictionary)
+}
+
+func (g *game) SetScoringStrategy(strategy oshirigame.ScoringStrategy) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Scoring = strategy.Name()
+	g.GameState.ScoringFormula = strategy.Formula()
+}
+
+func (g *game) GetScoringStrategy() oshirigame.ScoringStrategy {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	strategy, _ := oshirigame.GetScoringStrategy(g.GameState.Scoring)
+	return strategy
+}
+
+func (g *game) SetInflection(policy string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Inflection = policy
+}
+
+func (g *game) GetInflection() string {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return g.GameState.Inflection
+}
+
+// ApplyInflection decides what happens to an inflection of lemma under the
+// room's inflection policy.
+func (g *game) ApplyInflection(lemma string) *InflectionResponse {
+	policy := g.GetInflection()
+	decision := InflectionAllowed
+	switch policy {
+	case oshirigame.InflectionBan:
+		decision = InflectionRejected
+	case oshirigame.InflectionLemma:
+		decision = InflectionScoredAsLemma
+	}
+	return &InflectionResponse{
+		Lemma:    lemma,
+		Policy:   policy,
+		Decision: decision,
+	}
+}
+
+func (g *game) SetFilter(filter string) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Filter = filter
+}
+
+func (g *game) GetFilter() string {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return g.GameState.Filter
+}
+
+// Blocked reports whether text is caught by the room's filter level.
+func (g *game) Blocked(text string) bool {
+	return oshirigame.GetBlocklist().Blocks(text, g.GetFilter())
+}
+
+// BlockedWord reports whether a played or suggested dictionary word is
+// blocked. Unlike usernames and chat, words are only matched whole.
+func (g *game) BlockedWord(word string) bool {
+	return oshirigame.GetBlocklist().BlocksWord(word, g.GetFilter())
+}
+
+func (g *game) SetFragmentLengths(atamaLength int, oshiriLength int) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if atamaLength > 0 {
+		g.GameState.AtamaLength = atamaLength
+	}
+	if oshiriLength > 0 {
+		g.GameState.OshiriLength = oshiriLength
+	}
+}
+
 func (g *game) SetRoundTime(time int) {
 	g.GameState.Lock()
 	defer g.GameState.Unlock()
@@ -522,6 +1269,73 @@ func (p *Player) GetPlayerScore() int {
 	return p.Score
 }
 
+func (p *Player) GetHistory() []oshirigame.RoundResult {
+	p.Lock()
+	defer p.Unlock()
+	return append([]oshirigame.RoundResult(nil), p.history...)
+}
+
+func (p *Player) AddHistory(result oshirigame.RoundResult) {
+	p.Lock()
+	defer p.Unlock()
+	p.history = append(p.history, result)
+}
+
+// UndoLastResult removes the player's last round result if it was for word,
+// so an overturned answer can be scored again.
+func (p *Player) UndoLastResult(word string) {
+	p.Lock()
+	defer p.Unlock()
+	if n := len(p.history); n > 0 && p.history[n-1].Word == word {
+		p.history = p.history[:n-1]
+	}
+}
+
+func (p *Player) ClearHistory() {
+	p.Lock()
+	defer p.Unlock()
+	p.history = nil
+}
+
+// HasPlayed reports whether the player had word accepted this game.
+func (p *Player) HasPlayed(word string) bool {
+	for _, result := range p.GetHistory() {
+		if result.Accepted && result.Word == word {
+			return true
+		}
+	}
+	return false
+}
+
+// PlayedWords lists the player's accepted words once each, in the order they
+// first played them.
+func (p *Player) PlayedWords() []string {
+	words := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, result := range p.GetHistory() {
+		if result.Accepted && !seen[result.Word] {
+			seen[result.Word] = true
+			words = append(words, result.Word)
+		}
+	}
+	return words
+}
+
+// SetInput records a player's own answer for simultaneous play.
+func (p *Player) SetInput(input string, inputTime int) {
+	p.Lock()
+	defer p.Unlock()
+	p.input = input
+	p.inputTime = inputTime
+	p.Answered = input != ""
+}
+
+func (p *Player) GetInput() (string, int) {
+	p.Lock()
+	defer p.Unlock()
+	return p.input, p.inputTime
+}
+
 func (p *Player) SetPlayerClient(client *client) {
 	p.Lock()
 	defer p.Unlock()
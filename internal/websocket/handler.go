@@ -2,10 +2,12 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/carl1330/oshirigame/internal/oshirigame"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
@@ -31,9 +33,28 @@ const (
 	UPDATE_GAME_OPTIONS = "UPDATE_GAME_OPTIONS"
 	GAME_OVER           = "GAME_OVER"
 	RESET_GAME          = "RESET_GAME"
+	CHAT_MESSAGE        = "CHAT_MESSAGE"
+	JOIN_TEAM           = "JOIN_TEAM"
+	TEAM_SUGGESTION     = "TEAM_SUGGESTION"
+	STEAL_START         = "STEAL_START"
+	STEAL_ATTEMPT       = "STEAL_ATTEMPT"
+	STEAL_REJECTED      = "STEAL_REJECTED"
+	CHALLENGE           = "CHALLENGE"
+	CHALLENGE_START     = "CHALLENGE_START"
+	VOTE                = "VOTE"
+	CHALLENGE_RESULT    = "CHALLENGE_RESULT"
 	ERROR               = "ERROR"
 )
 
+const (
+	// Longest atama or oshiri a room can ask for.
+	maxFragmentLength = 3
+	// Longest chat message accepted, in bytes.
+	maxChatLength = 250
+	// Longest letter suggestion a teammate can send, in bytes.
+	maxSuggestionLength = 20
+)
+
 type handler struct {
 	hub *hub
 }
@@ -64,23 +85,167 @@ type GameOptionsUpdateMessage struct {
 	MaxRounds           int
 	RoundTime           int
 	MinWordCombinations int
+	Language            string
+	AtamaLength         int
+	OshiriLength        int
+	Filter              string
+	Scoring             string
+	Inflection          string
+	Difficulty          string
+	Seed                *int64 // nil leaves the seed unchanged, 0 clears it
+	AtamaPool           string
+	OshiriPool          string
+	ExcludedLetters     []string // Null leaves them unchanged, an empty list clears them
+	LetterSource        string
+	Mode                string
+	ChainOshiri         string
+	NoRepeat            string
+	RepeatPenalty       string
+	Teams               int
+	Lives               *int // nil leaves elimination unchanged, 0 turns it off
+	StealTime           *int // nil leaves stealing unchanged, 0 turns it off
+	ChallengeTime       *int // nil leaves appeals unchanged, 0 turns them off
+	ChallengeSave       string
+}
+
+type ChatMessage struct {
+	Message string
+}
+
+type JoinTeamMessage struct {
+	Team int
+}
+
+type TeamSuggestionMessage struct {
+	Letters string
+}
+
+type StealAttemptMessage struct {
+	Input string
+}
+
+type VoteMessage struct {
+	Accept bool
 }
 
 type RoundOverResponse struct {
-	TopWords     []string        `json:"topWords"`
-	GameState    json.RawMessage `json:"gameState"`
-	Word         string          `json:"word"`
-	WordAccepted bool            `json:"wordAccepted"`
+	TopWords     []string                  `json:"topWords"`
+	GameState    json.RawMessage           `json:"gameState"`
+	Word         string                    `json:"word"`
+	WordAccepted bool                      `json:"wordAccepted"`
+	Score        oshirigame.ScoreBreakdown `json:"score"`
+	Definitions  map[string]string         `json:"definitions,omitempty"`
+	Suggestions  []string                  `json:"suggestions,omitempty"`
+	Inflection   *InflectionResponse       `json:"inflection,omitempty"`
+	Repeated     bool                      `json:"repeated,omitempty"`
+	// Results holds every player's answer in simultaneous play, where the
+	// single word fields above are left empty.
+	Results []PlayerResult `json:"results,omitempty"`
+	// Eliminated lists players who lost their last life this round.
+	Eliminated []string `json:"eliminated,omitempty"`
+	// Steal is the answer that stole the pair after the active player
+	// failed it.
+	Steal *PlayerResult `json:"steal,omitempty"`
+}
+
+// PlayerResult is how one player's answer was judged and scored.
+type PlayerResult struct {
+	Username     string                    `json:"username"`
+	Word         string                    `json:"word"`
+	WordAccepted bool                      `json:"wordAccepted"`
+	Score        oshirigame.ScoreBreakdown `json:"score"`
+	Inflection   *InflectionResponse       `json:"inflection,omitempty"`
+	Suggestions  []string                  `json:"suggestions,omitempty"`
+	Unique       bool                      `json:"unique,omitempty"`
+	Repeated     bool                      `json:"repeated,omitempty"` // Caught by the no-repeat rule
+}
+
+// Decisions the inflection policy can make about an inflected word.
+const (
+	InflectionAllowed       = "allowed"
+	InflectionRejected      = "rejected"
+	InflectionScoredAsLemma = "scoredAsLemma"
+)
+
+// InflectionResponse explains how the room's inflection policy treated an
+// inflected word.
+type InflectionResponse struct {
+	Lemma    string `json:"lemma"`
+	Policy   string `json:"policy"`
+	Decision string `json:"decision"`
+}
+
+// LetterResponse announces an atama or oshiri. Length is counted in the units
+// the room plays with, so kana yōon count as one.
+type LetterResponse struct {
+	Letter string `json:"letter"`
+	Length int    `json:"length"`
+}
+
+func NewLetterResponse(dictionary oshirigame.Dictionary, fragment string) *LetterResponse {
+	return &LetterResponse{
+		Letter: fragment,
+		Length: dictionary.Language().Length(fragment),
+	}
+}
+
+type ChatResponse struct {
+	Username string `json:"username"`
+	Message  string `json:"message"`
+}
+
+type TeamSuggestionResponse struct {
+	Username string `json:"username"`
+	Letters  string `json:"letters"`
+}
+
+// StealStartResponse opens the steal window after Username failed the pair.
+type StealStartResponse struct {
+	Username string `json:"username"`
+	Time     int    `json:"time"`
+}
+
+type StealRejectedResponse struct {
+	Word string `json:"word"`
+}
+
+// ChallengeStartResponse opens a vote on Username's rejected Word.
+type ChallengeStartResponse struct {
+	Username string `json:"username"`
+	Word     string `json:"word"`
+	Time     int    `json:"time"`
+}
+
+// ChallengeResultResponse is how a challenge vote went. Score and Saved are
+// only set when the appeal was accepted.
+type ChallengeResultResponse struct {
+	Username string                     `json:"username"`
+	Word     string                     `json:"word"`
+	Accepted bool                       `json:"accepted"`
+	For      int                        `json:"for"`
+	Against  int                        `json:"against"`
+	Score    *oshirigame.ScoreBreakdown `json:"score,omitempty"`
+	Saved    string                     `json:"saved,omitempty"`
+}
+
+type UploadWordListResponse struct {
+	Words            int `json:"words"`
+	WordCombinations int `json:"wordCombinations"`
 }
 
 type GameOverResponse struct {
-	Winners []PlayerRanking `json:"winners"`
+	Winners   []PlayerRanking `json:"winners"`
+	Reason    string          `json:"reason,omitempty"`
+	UsedWords []string        `json:"usedWords"`
+	Teams     []TeamRanking   `json:"teams,omitempty"`
+	Seed      int64           `json:"seed"` // Set it in the game options to replay the same letters
 }
 
 type PlayerRanking struct {
-	Username string `json:"username"`
-	Score    int    `json:"score"`
-	Rank     int    `json:"rank"`
+	Username  string   `json:"username"`
+	Score     int      `json:"score"`
+	Rank      int      `json:"rank"`
+	UsedWords []string `json:"usedWords,omitempty"` // Only with the per-player no-repeat rule
 }
 
 type ErrorResponse struct {
@@ -121,12 +286,65 @@ func (h *handler) ServeWS(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) CreateGame(w http.ResponseWriter, r *http.Request) {
-	game := NewGame()
+	game, err := NewGame()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	h.hub.addgame <- game
 	w.Write([]byte(fmt.Sprintf(`{"id": "%s"}`, game.Id)))
 	go game.Run()
 }
 
+func (h *handler) Languages(w http.ResponseWriter, r *http.Request) {
+	data, _ := json.Marshal(oshirigame.Languages())
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// UploadWordList replaces a room's dictionary with a word list posted by the
+// room leader, one word per line, before the game starts.
+func (h *handler) UploadWordList(w http.ResponseWriter, r *http.Request) {
+	game, err := h.hub.GetGame(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	player, err := game.GetPlayer(r.URL.Query().Get("token"))
+	if err != nil || !player.IsLeader {
+		http.Error(w, "only the room leader can upload a word list", http.StatusForbidden)
+		return
+	}
+
+	if game.GetStarted() {
+		http.Error(w, "can't change the word list once the game has started", http.StatusConflict)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, oshirigame.MaxCustomWordListSize)
+	wordList, err := oshirigame.NewCustomWordList(r.Body)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(w, "word list is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	game.SetDictionary(wordList)
+	data, _ := json.Marshal(&UploadWordListResponse{
+		Words:            wordList.Len(),
+		WordCombinations: game.LimitWordCombinations(),
+	})
+	game.BroadcastGameState()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
 func (h *hub) JoinRoom(m *Message, c *client) error {
 	var joinRoomMessage JoinRoomMessage
 	err := json.Unmarshal(m.Data, &joinRoomMessage)
@@ -153,6 +371,11 @@ func (h *hub) JoinRoom(m *Message, c *client) error {
 		return err
 	}
 
+	if game.Blocked(joinRoomMessage.Username) {
+		c.SendError("Username not allowed")
+		return fmt.Errorf("username not allowed")
+	}
+
 	if game.GetStarted() {
 		errMsg := &ErrorResponse{
 			Message: "Can't join room, game has already started",
@@ -199,16 +422,17 @@ func (h *hub) StartGame(m *Message, c *client) error {
 		return err
 	}
 
+	if !game.PairSettings().Feasible() {
+		c.SendError("No atama and oshiri fit the room's settings")
+		return fmt.Errorf("no pair satisfies the game options")
+	}
+
 	game.InitializeGame()
 	go game.StartRound()
 	game.GameState.Lock()
 	data, _ := json.Marshal(game.GameState)
 	game.GameState.Unlock()
-	message := &Message{
-		Type: START_GAME,
-		Data: data,
-	}
-	game.broadcast <- message
+	game.BroadcastMessage(START_GAME, data)
 
 	return nil
 }
@@ -230,6 +454,11 @@ func (h *hub) StartRound(m *Message, c *client) error {
 		return fmt.Errorf("player is not leader")
 	}
 
+	if game.Challenging() {
+		c.SendError("Wait for the challenge vote to finish")
+		return fmt.Errorf("challenge in progress")
+	}
+
 	if !game.IsRunning() {
 		go game.StartRound()
 	}
@@ -243,12 +472,14 @@ func (h *hub) NextRound(m *Message, c *client) error {
 		return err
 	}
 
+	if game.Challenging() {
+		c.SendError("Wait for the challenge vote to finish")
+		return fmt.Errorf("challenge in progress")
+	}
+
 	data, _ := json.Marshal(game.GameState)
 
-	game.broadcast <- &Message{
-		Type: NEXT_ROUND,
-		Data: data,
-	}
+	game.BroadcastMessage(NEXT_ROUND, data)
 
 	if !game.IsRunning() {
 		go game.StartRound()
@@ -276,7 +507,12 @@ func (h *hub) PlayerInput(m *Message, c *client) error {
 		return err
 	}
 
-	if !player.IsLeader {
+	if game.IsSpectator(player) {
+		return fmt.Errorf("player is eliminated")
+	}
+
+	simultaneous := game.GetMode() == ModeSimultaneous
+	if !player.IsLeader && !simultaneous {
 		return fmt.Errorf("player is not leader")
 	}
 
@@ -284,10 +520,26 @@ func (h *hub) PlayerInput(m *Message, c *client) error {
 		return fmt.Errorf("game not started")
 	}
 
+	input := game.GetDictionary().Normalize(playerInputMessage.Input)
+
 	game.GameState.Lock()
-	game.GameState.Input = strings.ToLower(playerInputMessage.Input)
+	if !game.GameState.Answering {
+		game.GameState.Unlock()
+		return fmt.Errorf("round has not started")
+	}
+	inputTime := game.GameState.Time
+	if !simultaneous {
+		game.GameState.Input = input
+		game.GameState.InputTime = inputTime
+	}
 	game.GameState.Unlock()
 
+	// In simultaneous play answers stay private until the round is over, so
+	// the room only learns who has answered.
+	if simultaneous {
+		player.SetInput(input, inputTime)
+	}
+
 	game.BroadcastGameState()
 
 	return nil
@@ -307,9 +559,161 @@ func (h *hub) UpdateGameOptions(m *Message, c *client) error {
 		return err
 	}
 
+	if !validFragmentLength(gameOptionsUpdateMessage.AtamaLength) || !validFragmentLength(gameOptionsUpdateMessage.OshiriLength) {
+		c.SendError(fmt.Sprintf("Atama and oshiri must be between 1 and %d letters long", maxFragmentLength))
+		return fmt.Errorf("invalid fragment length")
+	}
+
+	if gameOptionsUpdateMessage.Filter != "" && !oshirigame.ValidFilter(gameOptionsUpdateMessage.Filter) {
+		c.SendError("Unknown filter level")
+		return fmt.Errorf("unknown filter level %q", gameOptionsUpdateMessage.Filter)
+	}
+
+	scoring, ok := oshirigame.GetScoringStrategy(gameOptionsUpdateMessage.Scoring)
+	if gameOptionsUpdateMessage.Scoring != "" && !ok {
+		c.SendError("Unknown scoring mode")
+		return fmt.Errorf("unknown scoring mode %q", gameOptionsUpdateMessage.Scoring)
+	}
+
+	if gameOptionsUpdateMessage.Inflection != "" && !oshirigame.ValidInflection(gameOptionsUpdateMessage.Inflection) {
+		c.SendError("Unknown inflection policy")
+		return fmt.Errorf("unknown inflection policy %q", gameOptionsUpdateMessage.Inflection)
+	}
+
+	if gameOptionsUpdateMessage.Difficulty != "" && !oshirigame.ValidDifficulty(gameOptionsUpdateMessage.Difficulty) {
+		c.SendError("Unknown difficulty")
+		return fmt.Errorf("unknown difficulty %q", gameOptionsUpdateMessage.Difficulty)
+	}
+
+	if gameOptionsUpdateMessage.Mode != "" && !validMode(gameOptionsUpdateMessage.Mode) {
+		c.SendError("Unknown game mode")
+		return fmt.Errorf("unknown game mode %q", gameOptionsUpdateMessage.Mode)
+	}
+
+	if gameOptionsUpdateMessage.ChainOshiri != "" && !validChainOshiri(gameOptionsUpdateMessage.ChainOshiri) {
+		c.SendError("Unknown chain oshiri setting")
+		return fmt.Errorf("unknown chain oshiri setting %q", gameOptionsUpdateMessage.ChainOshiri)
+	}
+
+	if gameOptionsUpdateMessage.NoRepeat != "" && !validNoRepeat(gameOptionsUpdateMessage.NoRepeat) {
+		c.SendError("Unknown no-repeat rule")
+		return fmt.Errorf("unknown no-repeat rule %q", gameOptionsUpdateMessage.NoRepeat)
+	}
+
+	if gameOptionsUpdateMessage.RepeatPenalty != "" && !validRepeatPenalty(gameOptionsUpdateMessage.RepeatPenalty) {
+		c.SendError("Unknown repeat penalty")
+		return fmt.Errorf("unknown repeat penalty %q", gameOptionsUpdateMessage.RepeatPenalty)
+	}
+
+	if gameOptionsUpdateMessage.Lives != nil && !validLives(*gameOptionsUpdateMessage.Lives) {
+		c.SendError(fmt.Sprintf("Lives must be between 0 and %d", maxLives))
+		return fmt.Errorf("invalid lives %d", *gameOptionsUpdateMessage.Lives)
+	}
+
+	if gameOptionsUpdateMessage.StealTime != nil && !validStealTime(*gameOptionsUpdateMessage.StealTime) {
+		c.SendError(fmt.Sprintf("Steal time must be between 0 and %d seconds", maxStealTime))
+		return fmt.Errorf("invalid steal time %d", *gameOptionsUpdateMessage.StealTime)
+	}
+
+	if gameOptionsUpdateMessage.ChallengeTime != nil && !validChallengeTime(*gameOptionsUpdateMessage.ChallengeTime) {
+		c.SendError(fmt.Sprintf("Challenge time must be between 0 and %d seconds", maxChallengeTime))
+		return fmt.Errorf("invalid challenge time %d", *gameOptionsUpdateMessage.ChallengeTime)
+	}
+
+	if gameOptionsUpdateMessage.ChallengeSave != "" && !validChallengeSave(gameOptionsUpdateMessage.ChallengeSave) {
+		c.SendError("Accepted challenges can be saved off, room or server")
+		return fmt.Errorf("invalid challenge save %q", gameOptionsUpdateMessage.ChallengeSave)
+	}
+
+	if !validTeams(gameOptionsUpdateMessage.Teams) {
+		c.SendError(fmt.Sprintf("Rooms can have at most %d teams", maxTeams))
+		return fmt.Errorf("invalid team count %d", gameOptionsUpdateMessage.Teams)
+	}
+
+	if gameOptionsUpdateMessage.LetterSource != "" && !oshirigame.ValidLetterSource(gameOptionsUpdateMessage.LetterSource) {
+		c.SendError("Unknown letter source")
+		return fmt.Errorf("unknown letter source %q", gameOptionsUpdateMessage.LetterSource)
+	}
+
+	for _, pool := range []string{gameOptionsUpdateMessage.AtamaPool, gameOptionsUpdateMessage.OshiriPool} {
+		if pool != "" && !oshirigame.ValidPool(pool) {
+			c.SendError("Unknown letter pool")
+			return fmt.Errorf("unknown letter pool %q", pool)
+		}
+	}
+
+	dictionary := game.GetDictionary()
+	if gameOptionsUpdateMessage.Language != "" {
+		dictionary, err = oshirigame.GetDictionary(gameOptionsUpdateMessage.Language)
+		if err != nil {
+			c.SendError("Language not supported")
+			return err
+		}
+	}
+
+	if gameOptionsUpdateMessage.ExcludedLetters != nil {
+		excluded, ok := normalizeLetters(dictionary, gameOptionsUpdateMessage.ExcludedLetters)
+		if !ok {
+			c.SendError("Excluded letters must be single letters")
+			return fmt.Errorf("invalid excluded letters")
+		}
+		gameOptionsUpdateMessage.ExcludedLetters = excluded
+	}
+
+	if !feasibleOptions(game, dictionary, &gameOptionsUpdateMessage) {
+		c.SendError("No atama and oshiri fit these settings, try a lower word count or another difficulty")
+		return fmt.Errorf("no pair satisfies the game options")
+	}
+
+	if gameOptionsUpdateMessage.Language != "" {
+		game.SetDictionary(dictionary)
+	}
+
 	game.SetMaxRounds(gameOptionsUpdateMessage.MaxRounds)
 	game.SetWordCombinations(gameOptionsUpdateMessage.MinWordCombinations)
 	game.SetRoundTime(gameOptionsUpdateMessage.RoundTime)
+	game.SetFragmentLengths(gameOptionsUpdateMessage.AtamaLength, gameOptionsUpdateMessage.OshiriLength)
+	if gameOptionsUpdateMessage.Filter != "" {
+		game.SetFilter(gameOptionsUpdateMessage.Filter)
+	}
+	if scoring != nil {
+		game.SetScoringStrategy(scoring)
+	}
+	if gameOptionsUpdateMessage.Inflection != "" {
+		game.SetInflection(gameOptionsUpdateMessage.Inflection)
+	}
+	if gameOptionsUpdateMessage.Difficulty != "" {
+		game.SetDifficulty(gameOptionsUpdateMessage.Difficulty)
+	}
+	if gameOptionsUpdateMessage.Seed != nil {
+		game.SetSeed(*gameOptionsUpdateMessage.Seed)
+	}
+	game.SetLetterPools(gameOptionsUpdateMessage.AtamaPool, gameOptionsUpdateMessage.OshiriPool)
+	if gameOptionsUpdateMessage.ExcludedLetters != nil {
+		game.SetExcludedLetters(gameOptionsUpdateMessage.ExcludedLetters)
+	}
+	if gameOptionsUpdateMessage.LetterSource != "" {
+		game.SetLetterSource(gameOptionsUpdateMessage.LetterSource)
+	}
+	if gameOptionsUpdateMessage.Mode != "" {
+		game.SetMode(gameOptionsUpdateMessage.Mode)
+	}
+	if gameOptionsUpdateMessage.ChainOshiri != "" {
+		game.SetChainOshiri(gameOptionsUpdateMessage.ChainOshiri)
+	}
+	game.SetNoRepeat(gameOptionsUpdateMessage.NoRepeat, gameOptionsUpdateMessage.RepeatPenalty)
+	game.SetTeams(gameOptionsUpdateMessage.Teams)
+	if gameOptionsUpdateMessage.Lives != nil {
+		game.SetLives(*gameOptionsUpdateMessage.Lives)
+	}
+	if gameOptionsUpdateMessage.StealTime != nil {
+		game.SetStealTime(*gameOptionsUpdateMessage.StealTime)
+	}
+	challengeTime := -1
+	if gameOptionsUpdateMessage.ChallengeTime != nil {
+		challengeTime = *gameOptionsUpdateMessage.ChallengeTime
+	}
+	game.SetChallenges(challengeTime, gameOptionsUpdateMessage.ChallengeSave)
 
 	game.BroadcastGameState()
 
@@ -342,3 +746,266 @@ func (h *hub) ResetGame(m *Message, c *client) error {
 
 	return nil
 }
+
+// feasibleOptions reports whether any atama and oshiri would satisfy the game's
+// settings once the options are applied, with dictionary as its dictionary.
+func feasibleOptions(game *game, dictionary oshirigame.Dictionary, options *GameOptionsUpdateMessage) bool {
+	settings := game.PairSettings()
+	settings.dictionary = dictionary
+	settings.wordCombinations = options.MinWordCombinations
+	if options.AtamaLength > 0 {
+		settings.atamaLength = options.AtamaLength
+	}
+	if options.OshiriLength > 0 {
+		settings.oshiriLength = options.OshiriLength
+	}
+	if options.Difficulty != "" {
+		settings.difficulty = options.Difficulty
+	}
+	if options.AtamaPool != "" {
+		settings.atamaPool = options.AtamaPool
+	}
+	if options.OshiriPool != "" {
+		settings.oshiriPool = options.OshiriPool
+	}
+	if options.ExcludedLetters != nil {
+		settings.excludedLetters = options.ExcludedLetters
+	}
+	if options.LetterSource != "" {
+		settings.letterSource = options.LetterSource
+	}
+	return settings.Feasible()
+}
+
+// normalizeLetters converts letters the way the dictionary converts input and
+// checks that each is a single letter, or a single mora for kana.
+func normalizeLetters(dictionary oshirigame.Dictionary, letters []string) ([]string, bool) {
+	normalized := make([]string, 0, len(letters))
+	for _, letter := range letters {
+		letter = dictionary.Normalize(strings.TrimSpace(letter))
+		if dictionary.Language().Length(letter) != 1 {
+			return nil, false
+		}
+		normalized = append(normalized, letter)
+	}
+	return normalized, true
+}
+
+// validFragmentLength accepts zero, which leaves the current length unchanged.
+func validFragmentLength(length int) bool {
+	return length >= 0 && length <= maxFragmentLength
+}
+
+func (h *hub) Chat(m *Message, c *client) error {
+	var chatMessage ChatMessage
+	err := json.Unmarshal(m.Data, &chatMessage)
+
+	if err != nil {
+		return fmt.Errorf("error unmarshalling message data")
+	}
+
+	game, err := h.GetGame(c.gameId)
+
+	if err != nil {
+		return err
+	}
+
+	player, err := game.GetPlayer(c.token)
+
+	if err != nil {
+		return err
+	}
+
+	if chatMessage.Message == "" || len(chatMessage.Message) > maxChatLength {
+		return fmt.Errorf("invalid chat message length")
+	}
+
+	data, _ := json.Marshal(&ChatResponse{
+		Username: player.Username,
+		Message:  oshirigame.GetBlocklist().Censor(chatMessage.Message, game.GetFilter()),
+	})
+	game.BroadcastMessage(CHAT_MESSAGE, data)
+
+	return nil
+}
+
+func (h *hub) JoinTeam(m *Message, c *client) error {
+	var joinTeamMessage JoinTeamMessage
+	err := json.Unmarshal(m.Data, &joinTeamMessage)
+
+	if err != nil {
+		return fmt.Errorf("error unmarshalling message data")
+	}
+
+	game, err := h.GetGame(c.gameId)
+
+	if err != nil {
+		return err
+	}
+
+	player, err := game.GetPlayer(c.token)
+
+	if err != nil {
+		return err
+	}
+
+	if game.GetStarted() {
+		c.SendError("Teams can only be changed in the lobby")
+		return fmt.Errorf("game already started")
+	}
+
+	if err := game.JoinTeam(player, joinTeamMessage.Team); err != nil {
+		c.SendError("No such team")
+		return err
+	}
+
+	game.BroadcastGameState()
+
+	return nil
+}
+
+// TeamSuggestion passes letters from a player to their teammates, so a team
+// can help whoever is typing.
+func (h *hub) TeamSuggestion(m *Message, c *client) error {
+	var teamSuggestionMessage TeamSuggestionMessage
+	err := json.Unmarshal(m.Data, &teamSuggestionMessage)
+
+	if err != nil {
+		return fmt.Errorf("error unmarshalling message data")
+	}
+
+	game, err := h.GetGame(c.gameId)
+
+	if err != nil {
+		return err
+	}
+
+	player, err := game.GetPlayer(c.token)
+
+	if err != nil {
+		return err
+	}
+
+	if !game.GetStarted() {
+		return fmt.Errorf("game has not started")
+	}
+
+	if teamSuggestionMessage.Letters == "" || len(teamSuggestionMessage.Letters) > maxSuggestionLength {
+		return fmt.Errorf("invalid suggestion length")
+	}
+
+	// Outside simultaneous play only the player whose turn it is types, so
+	// suggestions go to them alone
+	teammates := game.Teammates(player)
+	if game.GetMode() != ModeSimultaneous {
+		teammates = nil
+		if typist := game.Typist(game.Team(player)); typist != nil && typist != player {
+			teammates = []*Player{typist}
+		}
+	}
+	if len(teammates) == 0 {
+		return fmt.Errorf("no teammate is typing")
+	}
+
+	data, _ := json.Marshal(&TeamSuggestionResponse{
+		Username: player.Username,
+		Letters:  oshirigame.GetBlocklist().Censor(teamSuggestionMessage.Letters, game.GetFilter()),
+	})
+	for _, teammate := range teammates {
+		teammate.client.send <- &Message{
+			Type: TEAM_SUGGESTION,
+			Data: data,
+		}
+	}
+
+	return nil
+}
+
+// StealAttempt lets a player try for a pair the active player failed. Only
+// the first valid answer wins, and the room learns who in ROUND_FINISHED.
+func (h *hub) StealAttempt(m *Message, c *client) error {
+	var stealAttemptMessage StealAttemptMessage
+	err := json.Unmarshal(m.Data, &stealAttemptMessage)
+
+	if err != nil {
+		return fmt.Errorf("error unmarshalling message data")
+	}
+
+	game, err := h.GetGame(c.gameId)
+
+	if err != nil {
+		return err
+	}
+
+	player, err := game.GetPlayer(c.token)
+
+	if err != nil {
+		return err
+	}
+
+	input := game.GetDictionary().Normalize(stealAttemptMessage.Input)
+	stolen, err := game.Steal(player, input)
+
+	if err != nil {
+		return err
+	}
+
+	if !stolen {
+		game.GameState.Lock()
+		word := game.GameState.Atama + input + game.GameState.Oshiri
+		game.GameState.Unlock()
+		data, _ := json.Marshal(&StealRejectedResponse{Word: word})
+		c.send <- &Message{
+			Type: STEAL_REJECTED,
+			Data: data,
+		}
+	}
+
+	return nil
+}
+
+// Challenge appeals the player's rejected word from the last round, putting
+// it to a vote.
+func (h *hub) Challenge(m *Message, c *client) error {
+	game, err := h.GetGame(c.gameId)
+
+	if err != nil {
+		return err
+	}
+
+	player, err := game.GetPlayer(c.token)
+
+	if err != nil {
+		return err
+	}
+
+	if err := game.Challenge(player); err != nil {
+		c.SendError("That word can't be challenged right now")
+		return err
+	}
+
+	return nil
+}
+
+func (h *hub) Vote(m *Message, c *client) error {
+	var voteMessage VoteMessage
+	err := json.Unmarshal(m.Data, &voteMessage)
+
+	if err != nil {
+		return fmt.Errorf("error unmarshalling message data")
+	}
+
+	game, err := h.GetGame(c.gameId)
+
+	if err != nil {
+		return err
+	}
+
+	player, err := game.GetPlayer(c.token)
+
+	if err != nil {
+		return err
+	}
+
+	return game.Vote(player, voteMessage.Accept)
+}
@@ -11,7 +11,6 @@ type hub struct {
 	register   chan *client
 	unregister chan *client
 	addgame    chan *game
-	removegame chan *game
 	broadcast  chan *Message
 	handlers   map[string]MessageHandler
 	sync.Mutex
@@ -28,7 +27,6 @@ func NewHub() *hub {
 		register:   make(chan *client),
 		unregister: make(chan *client),
 		addgame:    make(chan *game),
-		removegame: make(chan *game),
 		broadcast:  make(chan *Message),
 		handlers:   make(map[string]MessageHandler),
 	}
@@ -39,6 +37,12 @@ func NewHub() *hub {
 	h.handlers[NEXT_ROUND] = h.NextRound
 	h.handlers[UPDATE_GAME_OPTIONS] = h.UpdateGameOptions
 	h.handlers[RESET_GAME] = h.ResetGame
+	h.handlers[CHAT_MESSAGE] = h.Chat
+	h.handlers[JOIN_TEAM] = h.JoinTeam
+	h.handlers[TEAM_SUGGESTION] = h.TeamSuggestion
+	h.handlers[STEAL_ATTEMPT] = h.StealAttempt
+	h.handlers[CHALLENGE] = h.Challenge
+	h.handlers[VOTE] = h.Vote
 	return h
 }
 
@@ -52,21 +56,21 @@ func (h *hub) Run() {
 		case client := <-h.unregister:
 			if game, ok := h.games[client.gameId]; ok {
 				game.unregister <- client
-				game.Lock()
-				if len(game.players) == 0 {
-					h.removegame <- game
+				// The last player left, so the room and any word list
+				// uploaded for it can go
+				if <-game.remaining == 0 {
+					h.Lock()
+					delete(h.games, game.Id)
+					h.Unlock()
+					game.Close()
+					game.DiscardCustomDictionary()
 				}
-				game.Unlock()
 			}
 			delete(h.clients, client.token)
 		case game := <-h.addgame:
 			h.Lock()
 			h.games[game.Id] = game
 			h.Unlock()
-		case game := <-h.removegame:
-			h.Lock()
-			delete(h.games, game.Id)
-			h.Unlock()
 		case message := <-h.broadcast:
 			for _, client := range h.clients {
 				client.send <- message
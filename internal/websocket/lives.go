@@ -0,0 +1,165 @@
+package websocket
+
+// maxLives is the most lives a player can start an elimination game with.
+const maxLives = 10
+
+func validLives(lives int) bool {
+	return lives >= 0 && lives <= maxLives
+}
+
+// SetLives turns on elimination, with every player starting on the given
+// number of lives. Zero turns it off.
+func (g *game) SetLives(lives int) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Lives = lives
+}
+
+// Elimination reports whether players can run out of lives.
+func (g *game) Elimination() bool {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return g.GameState.Lives > 0
+}
+
+// ResetLives brings spectators back into the queue and gives every player
+// the room's starting lives.
+func (g *game) ResetLives() {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.PlayerQueue = append(g.GameState.PlayerQueue, g.GameState.Spectators...)
+	g.GameState.Spectators = make([]*Player, 0)
+	for i, player := range g.GameState.PlayerQueue {
+		player.Lives = g.GameState.Lives
+		player.Eliminated = false
+		player.IsLeader = i == 0
+	}
+}
+
+// StartLives gives a player who joins the room the starting lives, so that
+// they can play in an elimination game that is already under way.
+func (g *game) StartLives(player *Player) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	player.Lives = g.GameState.Lives
+	player.Eliminated = false
+}
+
+// LoseLife takes a life from a player and reports whether it was their last.
+// Eliminated players leave the turn queue and watch the rest of the game.
+func (g *game) LoseLife(player *Player) bool {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if g.GameState.Lives == 0 || player.Eliminated {
+		return false
+	}
+	player.Lives--
+	if player.Lives > 0 {
+		return false
+	}
+
+	player.Eliminated = true
+	player.IsLeader = false
+	queue := make([]*Player, 0, len(g.GameState.PlayerQueue))
+	for _, other := range g.GameState.PlayerQueue {
+		if other != player {
+			queue = append(queue, other)
+		}
+	}
+	if len(queue) > 0 {
+		queue[0].IsLeader = true
+	}
+	g.GameState.PlayerQueue = queue
+	g.GameState.Spectators = append(g.GameState.Spectators, player)
+	return true
+}
+
+// LoseLives takes a life from every player whose answer in simultaneous play
+// was rejected, marking the answers that cost one, and returns who was
+// eliminated. A round that would knock out every remaining player costs
+// nobody a life, so the game can't end without a winner.
+func (g *game) LoseLives(answers []answer) []string {
+	if !g.Elimination() {
+		return nil
+	}
+
+	failed := 0
+	for _, a := range answers {
+		if !a.accepted {
+			failed++
+		}
+	}
+	if failed == len(answers) {
+		return nil
+	}
+
+	var eliminated []string
+	for i, a := range answers {
+		if a.accepted {
+			continue
+		}
+		answers[i].lostLife = true
+		if g.LoseLife(a.player) {
+			eliminated = append(eliminated, a.player.Username)
+		}
+	}
+	return eliminated
+}
+
+// RestoreLife gives back a life taken for an answer that was later accepted,
+// bringing an eliminated player back into the turn queue.
+func (g *game) RestoreLife(player *Player) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	player.Lives++
+	if !player.Eliminated {
+		return
+	}
+
+	player.Eliminated = false
+	for i, spectator := range g.GameState.Spectators {
+		if spectator == player {
+			g.GameState.Spectators = append(g.GameState.Spectators[:i:i], g.GameState.Spectators[i+1:]...)
+			break
+		}
+	}
+	player.IsLeader = len(g.GameState.PlayerQueue) == 0
+	g.GameState.PlayerQueue = append(g.GameState.PlayerQueue, player)
+}
+
+// IsSpectator reports whether a player has been eliminated.
+func (g *game) IsSpectator(player *Player) bool {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return player.Eliminated
+}
+
+// LastStanding reports whether elimination has left at most one player, and
+// why the game ends. A game started alone runs until that player is out.
+func (g *game) LastStanding() (string, bool) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if g.GameState.Lives == 0 {
+		return "", false
+	}
+	switch remaining := len(g.GameState.PlayerQueue); {
+	case remaining == 0:
+		return "Every player was eliminated", true
+	case remaining == 1 && len(g.GameState.Spectators) > 0:
+		return g.GameState.PlayerQueue[0].Username + " is the last player standing", true
+	}
+	return "", false
+}
+
+// survival ranks how long a player lasted: players still in the game share
+// the highest value, and the earlier a player was eliminated the lower theirs.
+func (g *game) survival(player *Player) int {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	for i, spectator := range g.GameState.Spectators {
+		if spectator == player {
+			return i + 1
+		}
+	}
+	return len(g.GameState.Spectators) + 1
+}
@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/carl1330/oshirigame/internal/oshirigame"
+)
+
+// pairSettings are the options that decide which atama and oshiri a room can
+// be given.
+type pairSettings struct {
+	dictionary       oshirigame.Dictionary
+	atamaLength      int
+	oshiriLength     int
+	difficulty       string
+	wordCombinations int
+	atamaPool        string
+	oshiriPool       string
+	excludedLetters  []string
+	letterSource     string
+	chainOshiri      string
+	// bag is a copy of the game's letter bag, or nil before a bag game has
+	// started.
+	bag oshirigame.LetterBag
+}
+
+func (g *game) PairSettings() pairSettings {
+	dictionary := g.GetDictionary()
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return pairSettings{
+		dictionary:       dictionary,
+		atamaLength:      g.GameState.AtamaLength,
+		oshiriLength:     g.GameState.OshiriLength,
+		difficulty:       g.GameState.Difficulty,
+		wordCombinations: g.GameState.WordCombinations,
+		atamaPool:        g.GameState.AtamaPool,
+		oshiriPool:       g.GameState.OshiriPool,
+		excludedLetters:  g.GameState.ExcludedLetters,
+		letterSource:     g.GameState.LetterSource,
+		chainOshiri:      g.GameState.ChainOshiri,
+		bag:              g.GameState.Bag.Copy(),
+	}
+}
+
+// LetterPools builds the pools atama and oshiri are drawn from.
+func (s pairSettings) LetterPools() (*oshirigame.LetterPool, *oshirigame.LetterPool) {
+	atamaPool := oshirigame.NewLetterPool(s.dictionary, s.atamaLength, false, s.atamaPool, s.excludedLetters)
+	oshiriPool := oshirigame.NewLetterPool(s.dictionary, s.oshiriLength, true, s.oshiriPool, s.excludedLetters)
+	return atamaPool, oshiriPool
+}
+
+// Bag is the bag pairs are drawn from, filling a new one if the game has none
+// yet.
+func (s pairSettings) Bag() oshirigame.LetterBag {
+	if s.bag == nil {
+		return oshirigame.NewLetterBag(s.dictionary, s.excludedLetters)
+	}
+	return s.bag
+}
+
+// ratedPairs lists the pairs the difficulty and word combinations allow.
+func (s pairSettings) ratedPairs() []oshirigame.PairRating {
+	wordCombinations := s.wordCombinations
+	if s.dictionary.Language().Code == oshirigame.Custom {
+		wordCombinations = min(wordCombinations, s.dictionary.MaxWordCount(s.atamaLength, s.oshiriLength))
+	}
+	return oshirigame.EligiblePairs(s.dictionary, s.atamaLength, s.oshiriLength, s.difficulty, wordCombinations)
+}
+
+// Feasible reports whether a new game with these settings could be given any
+// atama and oshiri, so a letter bag is checked as it is when full.
+func (s pairSettings) Feasible() bool {
+	s.bag = nil
+	_, _, ok := s.PickPair(rand.New(rand.NewSource(1)))
+	return ok
+}
+
+// maxPairDraws is how many pairs PickPair draws before it falls back to
+// picking straight from the eligible pairs.
+const maxPairDraws = 1000
+
+// PickPair draws an atama and oshiri that the settings allow. With a letter
+// bag the tiles are taken out of the settings' bag. It reports false when no
+// pair can be drawn.
+func (s pairSettings) PickPair(rng *rand.Rand) (string, string, bool) {
+	rated := s.ratedPairs()
+	allowed := make(map[[2]string]bool, len(rated))
+	for _, pair := range rated {
+		allowed[[2]string{pair.Atama, pair.Oshiri}] = true
+	}
+
+	if s.letterSource == oshirigame.LetterSourceBag {
+		return s.pickFromBag(rng, rated, allowed)
+	}
+
+	atamaPool, oshiriPool := s.LetterPools()
+	var eligible []oshirigame.PairRating
+	for _, pair := range rated {
+		if atamaPool.Contains(pair.Atama) && oshiriPool.Contains(pair.Oshiri) {
+			eligible = append(eligible, pair)
+		}
+	}
+	if len(eligible) == 0 {
+		return "", "", false
+	}
+
+	// Drawing from the pools keeps the odds of the letters pairs are made of.
+	for i := 0; i < maxPairDraws; i++ {
+		atama := atamaPool.Pick(rng)
+		oshiri := oshiriPool.Pick(rng)
+		if allowed[[2]string{atama, oshiri}] {
+			return atama, oshiri, true
+		}
+	}
+	pair := eligible[rng.Intn(len(eligible))]
+	return pair.Atama, pair.Oshiri, true
+}
+
+func (s pairSettings) pickFromBag(rng *rand.Rand, rated []oshirigame.PairRating, allowed map[[2]string]bool) (string, string, bool) {
+	bag := s.Bag()
+	language := s.dictionary.Language()
+
+	var eligible []oshirigame.PairRating
+	for _, pair := range rated {
+		if bag.CanSpell(language, pair.Atama, pair.Oshiri) {
+			eligible = append(eligible, pair)
+		}
+	}
+	if len(eligible) == 0 {
+		return "", "", false
+	}
+
+	// Draw tiles the way players would, putting them back if they do not
+	// make an eligible pair.
+	for i := 0; i < maxPairDraws; i++ {
+		tiles := bag.Copy()
+		atama := drawFragment(rng, tiles, s.atamaLength)
+		oshiri := drawFragment(rng, tiles, s.oshiriLength)
+		if allowed[[2]string{atama, oshiri}] {
+			bag.Take(language, atama, oshiri)
+			return atama, oshiri, true
+		}
+	}
+	pair := eligible[rng.Intn(len(eligible))]
+	bag.Take(language, pair.Atama, pair.Oshiri)
+	return pair.Atama, pair.Oshiri, true
+}
+
+func drawFragment(rng *rand.Rand, bag oshirigame.LetterBag, n int) string {
+	var fragment strings.Builder
+	for i := 0; i < max(n, 1); i++ {
+		fragment.WriteString(bag.Draw(rng))
+	}
+	return fragment.String()
+}
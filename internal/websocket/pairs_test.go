@@ -0,0 +1,72 @@
+package websocket
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/carl1330/oshirigame/internal/oshirigame"
+)
+
+func bagSettings(t *testing.T, words string, bag oshirigame.LetterBag) pairSettings {
+	t.Helper()
+	dictionary, err := oshirigame.NewWordList(oshirigame.LanguageEnglish, strings.NewReader(words))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pairSettings{
+		dictionary:       dictionary,
+		atamaLength:      1,
+		oshiriLength:     1,
+		difficulty:       oshirigame.DifficultyCustom,
+		wordCombinations: 1,
+		letterSource:     oshirigame.LetterSourceBag,
+		chainOshiri:      ChainOshiriRandom,
+		bag:              bag,
+	}
+}
+
+func TestPickFromBagExhaustion(t *testing.T) {
+	s := bagSettings(t, "cat\ncot\n", oshirigame.LetterBag{"c": 2, "t": 2, "e": 1})
+	rng := rand.New(rand.NewSource(1))
+
+	var pairs []string
+	for {
+		atama, oshiri, ok := s.PickPair(rng)
+		if !ok {
+			break
+		}
+		pairs = append(pairs, atama+oshiri)
+		if len(pairs) > 3 {
+			t.Fatalf("drew %v from a bag of five tiles", pairs)
+		}
+	}
+
+	// Every draw takes its tiles out: two "ct" pairs use up the c and t
+	// tiles, leaving "e" which makes no pair on its own.
+	if len(pairs) != 2 {
+		t.Errorf("drew %v, want two pairs", pairs)
+	}
+	if s.bag.Total() != 1 || s.bag["e"] != 1 {
+		t.Errorf("bag left %v, want only e", s.bag)
+	}
+}
+
+func TestPickChainPairFromBag(t *testing.T) {
+	s := bagSettings(t, "cat\ncut\n", oshirigame.LetterBag{"t": 1})
+	rng := rand.New(rand.NewSource(1))
+
+	atama, oshiri, ok := s.PickChainPair(rng, "c")
+	if !ok || atama != "c" || oshiri != "t" {
+		t.Fatalf("PickChainPair = %q, %q, %v, want c, t, true", atama, oshiri, ok)
+	}
+	if s.bag["t"] != 0 {
+		t.Errorf("oshiri tile was not taken out of the bag: %v", s.bag)
+	}
+
+	// With the tile gone the chain carries on without an oshiri
+	atama, oshiri, ok = s.PickChainPair(rng, "c")
+	if !ok || atama != "c" || oshiri != "" {
+		t.Errorf("PickChainPair = %q, %q, %v, want c, \"\", true", atama, oshiri, ok)
+	}
+}
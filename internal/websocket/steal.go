@@ -0,0 +1,143 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// maxStealTime is the longest steal window a room can set, in seconds.
+const maxStealTime = 15
+
+func validStealTime(stealTime int) bool {
+	return stealTime >= 0 && stealTime <= maxStealTime
+}
+
+// SetStealTime sets how long the rest of the room has to steal a pair the
+// active player failed. Zero turns stealing off.
+func (g *game) SetStealTime(stealTime int) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.StealTime = stealTime
+}
+
+func (g *game) GetStealTime() int {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return g.GameState.StealTime
+}
+
+func (g *game) setStealing(stealing bool, time int) {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	g.GameState.Stealing = stealing
+	g.GameState.Time = time
+}
+
+// stealRound opens the round's pair to everyone but the player who failed
+// it, and waits for the first valid answer. It reports false when the window
+// closes, or the round is cancelled, without a steal.
+func (g *game) stealRound(ctx context.Context, failed *Player) (answer, bool) {
+	stealTime := g.GetStealTime()
+	if stealTime == 0 {
+		return answer{}, false
+	}
+	others := 0
+	for _, player := range g.QueuedPlayers() {
+		if player != failed {
+			others++
+		}
+	}
+	if others == 0 {
+		return answer{}, false
+	}
+
+	// The first valid answer fills the slot and closes the window
+	steals := make(chan answer, 1)
+	g.Lock()
+	g.steals = steals
+	g.stealFrom = failed
+	g.Unlock()
+
+	g.setStealing(true, stealTime)
+	data, _ := json.Marshal(&StealStartResponse{
+		Username: failed.Username,
+		Time:     stealTime,
+	})
+	g.BroadcastMessage(STEAL_START, data)
+
+	stolen, ok := g.waitForSteal(ctx, steals, stealTime)
+
+	// Close the window before draining it, so an answer that got in just
+	// as time ran out still counts and none can arrive after.
+	g.Lock()
+	g.steals = nil
+	g.stealFrom = nil
+	g.Unlock()
+	g.setStealing(false, 0)
+	if !ok {
+		select {
+		case stolen = <-steals:
+			ok = true
+		default:
+		}
+	}
+	return stolen, ok
+}
+
+func (g *game) waitForSteal(ctx context.Context, steals chan answer, stealTime int) (answer, bool) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	for i := stealTime; i > 0; i-- {
+		select {
+		case stolen := <-steals:
+			return stolen, true
+		case <-ticker.C:
+			g.DecreaseTime()
+			g.BroadcastGameState()
+		case <-ctx.Done():
+			return answer{}, false
+		}
+	}
+	return answer{}, false
+}
+
+// Steal tries a player's answer against the open steal window. It reports
+// whether the answer won the steal, which only the first valid answer does.
+func (g *game) Steal(player *Player, input string) (bool, error) {
+	g.Lock()
+	open, from := g.steals != nil, g.stealFrom
+	g.Unlock()
+	if !open {
+		return false, errors.New("no steal in progress")
+	}
+	if player == from {
+		return false, errors.New("player failed this pair")
+	}
+	if g.IsSpectator(player) {
+		return false, errors.New("player is eliminated")
+	}
+
+	g.GameState.Lock()
+	timeLeft, stealTime := g.GameState.Time, g.GameState.StealTime
+	g.GameState.Unlock()
+	a := g.judge(g.GetDictionary(), player, input, timeLeft)
+	// The speed bonus is measured against the steal window
+	a.timeLimit = stealTime
+	if !a.accepted {
+		return false, nil
+	}
+
+	g.Lock()
+	defer g.Unlock()
+	if g.steals == nil {
+		// Someone else got there first
+		return false, nil
+	}
+	// The winner closes the window, so nobody else gets in once the round
+	// has taken the answer
+	g.steals <- a
+	g.steals = nil
+	return true, nil
+}
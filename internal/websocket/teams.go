@@ -0,0 +1,186 @@
+package websocket
+
+import (
+	"fmt"
+	"sort"
+)
+
+// maxTeams is the most teams a room can be split into.
+const maxTeams = 4
+
+// TeamRanking is a team's place at the end of the game.
+type TeamRanking struct {
+	Team    int      `json:"team"`
+	Score   int      `json:"score"`
+	Rank    int      `json:"rank"`
+	Members []string `json:"members"`
+}
+
+func validTeams(teams int) bool {
+	return teams >= 0 && teams <= maxTeams
+}
+
+// SetTeams splits the room into teams, with 1 playing without teams and 0
+// leaving the setting unchanged. Players on a team that no longer exists are
+// moved to another one.
+func (g *game) SetTeams(teams int) {
+	if teams == 0 {
+		return
+	}
+	g.GameState.Lock()
+	g.GameState.Teams = teams
+	for _, player := range g.GameState.PlayerQueue {
+		if player.Team > teams || teams == 1 {
+			player.Team = 0
+		}
+	}
+	g.GameState.Unlock()
+
+	g.AssignTeams()
+}
+
+// AssignTeams puts every player without a team on the smallest team.
+func (g *game) AssignTeams() {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if g.GameState.Teams < 2 {
+		return
+	}
+
+	sizes := make([]int, g.GameState.Teams+1)
+	for _, player := range g.GameState.PlayerQueue {
+		sizes[player.Team]++
+	}
+	for _, player := range g.GameState.PlayerQueue {
+		if player.Team != 0 {
+			continue
+		}
+		smallest := 1
+		for team := 2; team <= g.GameState.Teams; team++ {
+			if sizes[team] < sizes[smallest] {
+				smallest = team
+			}
+		}
+		player.Team = smallest
+		sizes[smallest]++
+	}
+}
+
+// JoinTeam moves a player to the team they picked in the lobby.
+func (g *game) JoinTeam(player *Player, team int) error {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if g.GameState.Teams < 2 {
+		return fmt.Errorf("room has no teams")
+	}
+	if team < 1 || team > g.GameState.Teams {
+		return fmt.Errorf("no team %d", team)
+	}
+	player.Team = team
+	return nil
+}
+
+// AlternateTeams orders the turn queue so that teams take turns, keeping the
+// order of players within each team. Once the smaller teams run out of
+// players the rest of the larger teams follow in order.
+func (g *game) AlternateTeams() {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if g.GameState.Teams < 2 || len(g.GameState.PlayerQueue) == 0 {
+		return
+	}
+
+	members := make([][]*Player, g.GameState.Teams+1)
+	for _, player := range g.GameState.PlayerQueue {
+		members[player.Team] = append(members[player.Team], player)
+	}
+
+	queue := make([]*Player, 0, len(g.GameState.PlayerQueue))
+	for turn := 0; len(queue) < len(g.GameState.PlayerQueue); turn++ {
+		for _, team := range members {
+			if turn < len(team) {
+				queue = append(queue, team[turn])
+			}
+		}
+	}
+
+	for i, player := range queue {
+		player.IsLeader = i == 0
+	}
+	g.GameState.PlayerQueue = queue
+}
+
+// Teammates lists the other players on a player's team.
+func (g *game) Teammates(player *Player) []*Player {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	var teammates []*Player
+	if player.Team == 0 {
+		return teammates
+	}
+	for _, other := range g.GameState.PlayerQueue {
+		if other != player && other.Team == player.Team {
+			teammates = append(teammates, other)
+		}
+	}
+	return teammates
+}
+
+// Team returns the team a player is on, or 0 without teams.
+func (g *game) Team(player *Player) int {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	return player.Team
+}
+
+// Typist returns the player whose turn it is when they are on the team, or
+// nil during another team's turn.
+func (g *game) Typist(team int) *Player {
+	g.GameState.Lock()
+	defer g.GameState.Unlock()
+	if team == 0 || len(g.GameState.PlayerQueue) == 0 {
+		return nil
+	}
+	if typist := g.GameState.PlayerQueue[0]; typist.IsLeader && typist.Team == team {
+		return typist
+	}
+	return nil
+}
+
+// TeamRankings adds up the scores of each team's players, eliminated ones
+// included, and ranks the teams. It returns nil when the room plays without
+// teams.
+func (g *game) TeamRankings() []TeamRanking {
+	g.GameState.Lock()
+	teams := g.GameState.Teams
+	players := append([]*Player(nil), g.GameState.PlayerQueue...)
+	players = append(players, g.GameState.Spectators...)
+	g.GameState.Unlock()
+	if teams < 2 {
+		return nil
+	}
+
+	rankings := make([]TeamRanking, teams)
+	for i := range rankings {
+		rankings[i] = TeamRanking{Team: i + 1, Members: make([]string, 0)}
+	}
+	for _, player := range players {
+		if player.Team == 0 {
+			continue
+		}
+		rankings[player.Team-1].Score += player.GetPlayerScore()
+		rankings[player.Team-1].Members = append(rankings[player.Team-1].Members, player.Username)
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return rankings[i].Score > rankings[j].Score
+	})
+	for i := range rankings {
+		rankings[i].Rank = i + 1
+		// Tied teams share a rank
+		if i > 0 && rankings[i].Score == rankings[i-1].Score {
+			rankings[i].Rank = rankings[i-1].Rank
+		}
+	}
+	return rankings
+}
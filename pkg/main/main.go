@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/carl1330/oshirigame/internal/oshirigame"
 	"github.com/carl1330/oshirigame/internal/websocket"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -14,6 +19,45 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "hunspell" {
+		if err := exportHunspell(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	blocklist := flag.String("blocklist", "", "file with one blocked term per line, replacing the built-in list")
+	definitions := flag.String("definitions", "", "tab-separated word and definition file shown after each round")
+	supplementary := flag.String("supplementary", "", "file of words accepted by challenge votes, read at start and added to")
+	frequencies := flag.String("frequencies", "", "directory of freq_<code>.txt word frequency files, replacing the built-in ones")
+	flag.Parse()
+
+	if *blocklist != "" {
+		if err := oshirigame.LoadBlocklist(*blocklist); err != nil {
+			log.Fatalf("failed to load blocklist: %v", err)
+		}
+	}
+
+	if *definitions != "" {
+		if err := oshirigame.LoadDefinitions(*definitions); err != nil {
+			log.Fatalf("failed to load definitions: %v", err)
+		}
+	}
+
+	if *supplementary != "" {
+		if err := oshirigame.LoadSupplementary(*supplementary); err != nil {
+			log.Fatalf("failed to load supplementary words: %v", err)
+		}
+	}
+
+	if *frequencies != "" {
+		oshirigame.SetFrequencyDir(*frequencies)
+	}
+
+	if err := oshirigame.LoadDictionaries(); err != nil {
+		log.Fatalf("failed to load dictionaries: %v", err)
+	}
+
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Use(cors.Handler(cors.Options{
@@ -32,6 +76,15 @@ func main() {
 	// API routes (must be before static file handler)
 	r.Get("/creategame", handler.CreateGame)
 	r.Get("/ws", handler.ServeWS)
+	r.Get("/languages", handler.Languages)
+	r.Post("/wordlist", handler.UploadWordList)
+	r.Route("/dictionary/{language}", func(r chi.Router) {
+		r.Get("/words", handler.ListWords)
+		r.Get("/words/{word}", handler.CheckWord)
+		r.Get("/count", handler.CountWords)
+		r.Get("/match", handler.MatchWords)
+		r.Get("/pairs", handler.ListPairs)
+	})
 
 	// Serve static files
 	staticDir := "./static"
@@ -65,3 +118,64 @@ func main() {
 	fmt.Println("Starting server on :8080")
 	http.ListenAndServe(":8080", r)
 }
+
+// exportHunspell expands a Hunspell dictionary into a plain word list with one
+// lowercase word per line, ready to be dropped into the wordlists directory.
+func exportHunspell(args []string) error {
+	flags := flag.NewFlagSet("hunspell", flag.ExitOnError)
+	dicPath := flags.String("dic", "", "Hunspell .dic file")
+	affPath := flags.String("aff", "", "Hunspell .aff file")
+	outPath := flags.String("o", "", "output file, standard output if empty")
+	flags.Parse(args)
+
+	if *dicPath == "" || *affPath == "" {
+		flags.Usage()
+		return fmt.Errorf("both -dic and -aff are required")
+	}
+
+	aff, err := os.Open(*affPath)
+	if err != nil {
+		return err
+	}
+	defer aff.Close()
+
+	affixes, err := oshirigame.ParseAffixes(aff)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", *affPath, err)
+	}
+
+	dic, err := os.Open(*dicPath)
+	if err != nil {
+		return err
+	}
+	defer dic.Close()
+
+	seen := make(map[string]bool)
+	err = affixes.Expand(dic, func(word string, lemma string) {
+		seen[strings.ToLower(word)] = true
+	})
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", *dicPath, err)
+	}
+
+	words := make([]string, 0, len(seen))
+	for word := range seen {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	out := os.Stdout
+	if *outPath != "" {
+		out, err = os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	w := bufio.NewWriter(out)
+	for _, word := range words {
+		fmt.Fprintln(w, word)
+	}
+	return w.Flush()
+}